@@ -63,6 +63,49 @@ func (ls *LetStatement) String() string {
 	return out.String()
 }
 
+// x = value, x += value and the other assignments to an existing binding
+type AssignStatement struct {
+	Token    token.Token // the assignment operator token
+	Name     *Identifier
+	Operator string
+	Value    Expression
+}
+
+func (as *AssignStatement) statementNode()       {}
+func (as *AssignStatement) TokenLiteral() string { return as.Token.Literal }
+func (as *AssignStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString(as.Name.String())
+	out.WriteString(" " + as.Operator + " ")
+	if as.Value != nil {
+		out.WriteString(as.Value.String())
+	}
+
+	out.WriteString(";")
+	return out.String()
+}
+
+// arr[start:end] = value, replacing that range of arr in place
+type SliceAssignStatement struct {
+	Token  token.Token // = token
+	Target *SliceExpression
+	Value  Expression
+}
+
+func (sa *SliceAssignStatement) statementNode()       {}
+func (sa *SliceAssignStatement) TokenLiteral() string { return sa.Token.Literal }
+func (sa *SliceAssignStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString(sa.Target.String())
+	out.WriteString(" = ")
+	if sa.Value != nil {
+		out.WriteString(sa.Value.String())
+	}
+
+	out.WriteString(";")
+	return out.String()
+}
+
 type Identifier struct {
 	Token token.Token
 	Value string
@@ -90,6 +133,42 @@ func (r *ReturnStatement) String() string {
 	return out.String()
 }
 
+type BreakStatement struct {
+	Token token.Token // break token
+	Label *Identifier // loop to break out of, nil for the nearest one
+}
+
+func (bs *BreakStatement) statementNode()       {}
+func (bs *BreakStatement) TokenLiteral() string { return bs.Token.Literal }
+func (bs *BreakStatement) String() string {
+	if bs.Label != nil {
+		return bs.Token.Literal + " " + bs.Label.String() + ";"
+	}
+	return bs.Token.Literal + ";"
+}
+
+type ContinueStatement struct {
+	Token token.Token // continue token
+	Label *Identifier // loop to continue, nil for the nearest one
+}
+
+func (cs *ContinueStatement) statementNode()       {}
+func (cs *ContinueStatement) TokenLiteral() string { return cs.Token.Literal }
+func (cs *ContinueStatement) String() string {
+	if cs.Label != nil {
+		return cs.Token.Literal + " " + cs.Label.String() + ";"
+	}
+	return cs.Token.Literal + ";"
+}
+
+// the "name: " written before a labeled loop
+func labelPrefix(label *Identifier) string {
+	if label == nil {
+		return ""
+	}
+	return label.String() + ": "
+}
+
 type ExpressionStatement struct {
 	Token      token.Token
 	Expression Expression
@@ -114,6 +193,15 @@ func (il *IntegerLiteral) expressionNode()      {}
 func (il *IntegerLiteral) TokenLiteral() string { return il.Token.Literal }
 func (il *IntegerLiteral) String() string       { return il.Token.Literal }
 
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (fl *FloatLiteral) expressionNode()      {}
+func (fl *FloatLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FloatLiteral) String() string       { return fl.Token.Literal }
+
 type PrefixExpression struct {
 	Token    token.Token
 	Operator string
@@ -161,6 +249,14 @@ func (b *Boolean) expressionNode()      {}
 func (b *Boolean) TokenLiteral() string { return b.Token.Literal }
 func (b *Boolean) String() string       { return b.Token.Literal }
 
+type Null struct {
+	Token token.Token
+}
+
+func (n *Null) expressionNode()      {}
+func (n *Null) TokenLiteral() string { return n.Token.Literal }
+func (n *Null) String() string       { return n.Token.Literal }
+
 type IfExpression struct {
 	Token        token.Token
 	Condition    Expression
@@ -201,6 +297,99 @@ func (bs *BlockStatements) String() string {
 	return out.String()
 }
 
+type DoExpression struct {
+	Token token.Token // do token
+	Body  *BlockStatements
+}
+
+func (de *DoExpression) expressionNode()      {}
+func (de *DoExpression) TokenLiteral() string { return de.Token.Literal }
+func (de *DoExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("do {")
+	out.WriteString(de.Body.String())
+	out.WriteString("}")
+
+	return out.String()
+}
+
+type WhileStatement struct {
+	Token     token.Token // while token
+	Label     *Identifier // set by `name: while ...`, nil otherwise
+	Condition Expression
+	Body      *BlockStatements
+}
+
+func (ws *WhileStatement) statementNode()       {}
+func (ws *WhileStatement) TokenLiteral() string { return ws.Token.Literal }
+func (ws *WhileStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString(labelPrefix(ws.Label))
+	out.WriteString("while")
+	out.WriteString(ws.Condition.String())
+	out.WriteString(" ")
+	out.WriteString(ws.Body.String())
+
+	return out.String()
+}
+
+// for (init; condition; post) { body }, every clause may be left out
+type ForStatement struct {
+	Token     token.Token // for token
+	Label     *Identifier // set by `name: for ...`, nil otherwise
+	Init      Statement
+	Condition Expression
+	Post      Statement
+	Body      *BlockStatements
+}
+
+func (fs *ForStatement) statementNode()       {}
+func (fs *ForStatement) TokenLiteral() string { return fs.Token.Literal }
+func (fs *ForStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString(labelPrefix(fs.Label))
+	out.WriteString("for (")
+	if fs.Init != nil {
+		out.WriteString(strings.TrimSuffix(fs.Init.String(), ";"))
+	}
+	out.WriteString("; ")
+	if fs.Condition != nil {
+		out.WriteString(fs.Condition.String())
+	}
+	out.WriteString("; ")
+	if fs.Post != nil {
+		out.WriteString(strings.TrimSuffix(fs.Post.String(), ";"))
+	}
+	out.WriteString(") ")
+	out.WriteString(fs.Body.String())
+
+	return out.String()
+}
+
+// for (item in collection) { body }
+type ForInStatement struct {
+	Token      token.Token // for token
+	Label      *Identifier // set by `name: for ...`, nil otherwise
+	Variable   *Identifier
+	Collection Expression
+	Body       *BlockStatements
+}
+
+func (fs *ForInStatement) statementNode()       {}
+func (fs *ForInStatement) TokenLiteral() string { return fs.Token.Literal }
+func (fs *ForInStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString(labelPrefix(fs.Label))
+	out.WriteString("for (")
+	out.WriteString(fs.Variable.String())
+	out.WriteString(" in ")
+	out.WriteString(fs.Collection.String())
+	out.WriteString(") ")
+	out.WriteString(fs.Body.String())
+
+	return out.String()
+}
+
 type FunctionLiteral struct {
 	Token      token.Token
 	Parameters []*Identifier
@@ -294,9 +483,36 @@ func (ie *IndexExpression) String() string {
 	return out.String()
 }
 
+type SliceExpression struct {
+	Token          token.Token // [ token
+	LeftExpression Expression
+	Start          Expression // nil when omitted
+	End            Expression // nil when omitted
+}
+
+func (se *SliceExpression) expressionNode()      {}
+func (se *SliceExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *SliceExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(se.LeftExpression.String())
+	out.WriteString("[")
+	if se.Start != nil {
+		out.WriteString(se.Start.String())
+	}
+	out.WriteString(":")
+	if se.End != nil {
+		out.WriteString(se.End.String())
+	}
+	out.WriteString("])")
+
+	return out.String()
+}
+
 type HashExpression struct {
-	Token token.Token
-	Pairs map[Expression]Expression
+	Token   token.Token
+	Spreads []Expression // ...expr entries, copied in order before Pairs
+	Pairs   map[Expression]Expression
 }
 
 func (ht *HashExpression) expressionNode()      {}
@@ -305,6 +521,9 @@ func (ht *HashExpression) String() string {
 	var out bytes.Buffer
 	out.WriteString("{")
 	pairs := []string{}
+	for _, spread := range ht.Spreads {
+		pairs = append(pairs, "..."+spread.String())
+	}
 	for key, value := range ht.Pairs {
 		pairs = append(pairs, key.String()+":"+value.String())
 	}
@@ -0,0 +1,133 @@
+package evaluator
+
+import (
+	"interpreter/ast"
+	"interpreter/lexer"
+	"interpreter/object"
+	"interpreter/parser"
+	"interpreter/token"
+)
+
+// everything Analyze learned about a piece of source
+type Result struct {
+	Tokens int                 // tokens lexed, not counting EOF
+	Nodes  int                 // nodes in the parsed program, the program included
+	Errors []parser.ParseError // parser errors, the program is not run when there are any
+	Value  object.Object       // result of evaluating the program, nil if it was not run
+}
+
+// lexes, parses and evaluates input in a fresh environment in one call
+func Analyze(input string) Result {
+	var res Result
+	l := lexer.New(input)
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		res.Tokens++
+	}
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	res.Nodes = countNodes(program)
+	res.Errors = p.ErrorDetails()
+	if len(res.Errors) > 0 {
+		return res
+	}
+	res.Value = Eval(program, object.NewEnviroment())
+	return res
+}
+
+// counts node and every node below it, skipping parts a failed parse left nil
+func countNodes(node ast.Node) int {
+	switch node := node.(type) {
+	case *ast.Program:
+		n := 1
+		for _, s := range node.Statements {
+			n += countNodes(s)
+		}
+		return n
+	case *ast.BlockStatements:
+		if node == nil {
+			return 0
+		}
+		n := 1
+		for _, s := range node.Statements {
+			n += countNodes(s)
+		}
+		return n
+	case *ast.LetStatement:
+		return 1 + countIdentifier(node.Name) + countNodes(node.Value)
+	case *ast.AssignStatement:
+		return 1 + countIdentifier(node.Name) + countNodes(node.Value)
+	case *ast.SliceAssignStatement:
+		return 1 + countNodes(node.Target) + countNodes(node.Value)
+	case *ast.ReturnStatement:
+		return 1 + countNodes(node.ReturnValue)
+	case *ast.ExpressionStatement:
+		return 1 + countNodes(node.Expression)
+	case *ast.PrefixExpression:
+		return 1 + countNodes(node.Right)
+	case *ast.InfixExpression:
+		return 1 + countNodes(node.Left) + countNodes(node.Right)
+	case *ast.IfExpression:
+		return 1 + countNodes(node.Condition) + countNodes(node.Consequence) + countNodes(node.Alternatives)
+	case *ast.DoExpression:
+		return 1 + countNodes(node.Body)
+	case *ast.WhileStatement:
+		return 1 + countIdentifier(node.Label) + countNodes(node.Condition) + countNodes(node.Body)
+	case *ast.ForStatement:
+		return 1 + countIdentifier(node.Label) + countNodes(node.Init) + countNodes(node.Condition) +
+			countNodes(node.Post) + countNodes(node.Body)
+	case *ast.ForInStatement:
+		return 1 + countIdentifier(node.Label) + countIdentifier(node.Variable) + countNodes(node.Collection) +
+			countNodes(node.Body)
+	case *ast.BreakStatement:
+		return 1 + countIdentifier(node.Label)
+	case *ast.ContinueStatement:
+		return 1 + countIdentifier(node.Label)
+	case *ast.FunctionLiteral:
+		n := 1 + countNodes(node.Body)
+		for _, param := range node.Parameters {
+			n += countIdentifier(param)
+		}
+		return n
+	case *ast.CallExpression:
+		n := 1 + countNodes(node.Function)
+		for _, arg := range node.Arguments {
+			n += countNodes(arg)
+		}
+		return n
+	case *ast.Array:
+		n := 1
+		for _, item := range node.Items {
+			n += countNodes(item)
+		}
+		return n
+	case *ast.IndexExpression:
+		return 1 + countNodes(node.LeftExpression) + countNodes(node.Index)
+	case *ast.SliceExpression:
+		if node == nil {
+			return 0
+		}
+		return 1 + countNodes(node.LeftExpression) + countNodes(node.Start) + countNodes(node.End)
+	case *ast.HashExpression:
+		n := 1
+		for _, spread := range node.Spreads {
+			n += countNodes(spread)
+		}
+		for key, val := range node.Pairs {
+			n += countNodes(key) + countNodes(val)
+		}
+		return n
+	case nil:
+		return 0
+	default:
+		// identifiers and literals have nothing below them
+		return 1
+	}
+}
+
+func countIdentifier(ident *ast.Identifier) int {
+	if ident == nil {
+		return 0
+	}
+	return 1
+}
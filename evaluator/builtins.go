@@ -1,10 +1,658 @@
 package evaluator
 
 import (
+	"bufio"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"interpreter/object"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// command-line arguments passed after the script name, returned by `args`
+var ScriptArgs = []string{}
+
+// random source behind builtins like `uuid`, seed it for reproducible runs
+var random = rand.New(rand.NewSource(time.Now().UnixNano()))
+
+func SeedRandom(seed int64) {
+	random = rand.New(rand.NewSource(seed))
+}
+
+// clock read by `measure`, defaults to the wall clock
+var now = time.Now
+
+// replaces the clock `measure` reads, pass time.Now to restore it
+func SetClock(clock func() time.Time) {
+	now = clock
+}
+
+// builtins that call back into the evaluator are registered here, a map
+// literal referring to applyFunction would be an initialization cycle
+func init() {
+	builtins["generator"] = &object.Builtin{Fn: generator}
+	builtins["times"] = &object.Builtin{Fn: times}
+	builtins["measure"] = &object.Builtin{Fn: measure}
+	builtins["builtins"] = &object.Builtin{Fn: builtinNames}
+	builtins["map"] = &object.Builtin{Fn: mapArray}
+	builtins["filter"] = &object.Builtin{Fn: filterArray}
+	builtins["reduce"] = &object.Builtin{Fn: reduceArray}
+	builtins["retry"] = &object.Builtin{Fn: retry}
+	builtins["sort"] = &object.Builtin{Fn: sortArray}
+}
+
+// calls fn up to n times until it returns something other than an error,
+// handing back the last error if every attempt fails
+func retry(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+	if !isCallable(args[0]) {
+		return newError("first argument to `retry` must be FUNCTION, got %s", args[0].Type())
+	}
+	n, ok := args[1].(*object.Integer)
+	if !ok {
+		return newError("second argument to `retry` must be INTEGER, got %s", args[1].Type())
+	}
+	if n.Value < 1 {
+		return newError("`retry` needs at least 1 attempt, got %d", n.Value)
+	}
+
+	var res object.Object
+	for i := int64(0); i < n.Value; i++ {
+		res = applyFunction(args[0], []object.Object{})
+		if !isError(res) {
+			return res
+		}
+	}
+	return res
+}
+
+// returns a new array of the elements of arr for which fn is truthy
+func filterArray(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+	arr, ok := args[0].(*object.Array)
+	if !ok {
+		return newError("first argument to `filter` must be ARRAY, got %s", args[0].Type())
+	}
+	if !isCallable(args[1]) {
+		return newError("second argument to `filter` must be FUNCTION, got %s", args[1].Type())
+	}
+
+	kept := []object.Object{}
+	for _, el := range arr.Elements {
+		res := applyFunction(args[1], []object.Object{el})
+		if isError(res) {
+			return res
+		}
+		if isTruthy(res) {
+			kept = append(kept, el)
+		}
+	}
+	return &object.Array{Elements: kept}
+}
+
+// folds arr from the left by calling fn(acc, element), starting from initial
+func reduceArray(args ...object.Object) object.Object {
+	if len(args) != 3 {
+		return newError("wrong number of arguments. got=%d, want=3", len(args))
+	}
+	arr, ok := args[0].(*object.Array)
+	if !ok {
+		return newError("first argument to `reduce` must be ARRAY, got %s", args[0].Type())
+	}
+	if !isCallable(args[2]) {
+		return newError("third argument to `reduce` must be FUNCTION, got %s", args[2].Type())
+	}
+
+	acc := args[1]
+	for _, el := range arr.Elements {
+		acc = applyFunction(args[2], []object.Object{acc, el})
+		if isError(acc) {
+			return acc
+		}
+	}
+	return acc
+}
+
+// returns a new array with the elements of arr in ascending order, numbers
+// compare by value and strings lexicographically unless a comparator is given
+func sortArray(args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=1 or 2", len(args))
+	}
+	arr, ok := args[0].(*object.Array)
+	if !ok {
+		return newError("first argument to `sort` must be ARRAY, got %s", args[0].Type())
+	}
+	elements := make([]object.Object, len(arr.Elements))
+	copy(elements, arr.Elements)
+
+	if len(args) == 2 {
+		if !isCallable(args[1]) {
+			return newError("second argument to `sort` must be FUNCTION, got %s", args[1].Type())
+		}
+		var failure object.Object
+		sort.SliceStable(elements, func(i, j int) bool {
+			if failure != nil {
+				return false
+			}
+			res := applyFunction(args[1], []object.Object{elements[i], elements[j]})
+			if isError(res) {
+				failure = res
+				return false
+			}
+			n, ok := res.(*object.Integer)
+			if !ok {
+				failure = newError("comparator passed to `sort` must return INTEGER, got %s", res.Type())
+				return false
+			}
+			return n.Value < 0
+		})
+		if failure != nil {
+			return failure
+		}
+		return &object.Array{Elements: elements}
+	}
+
+	if len(elements) == 0 {
+		return &object.Array{Elements: elements}
+	}
+	numeric := isNumber(elements[0])
+	if !numeric && elements[0].Type() != object.STRING_OBJ {
+		return newError("`sort` needs numbers or strings without a comparator, got %s", elements[0].Type())
+	}
+	for _, el := range elements {
+		if numeric && !isNumber(el) || !numeric && el.Type() != object.STRING_OBJ {
+			return newError("cannot sort %s together with %s", elements[0].Type(), el.Type())
+		}
+	}
+	if !numeric {
+		sort.SliceStable(elements, func(i, j int) bool {
+			return elements[i].(*object.String).Value < elements[j].(*object.String).Value
+		})
+		return &object.Array{Elements: elements}
+	}
+	sort.SliceStable(elements, func(i, j int) bool {
+		a, aok := elements[i].(*object.Integer)
+		b, bok := elements[j].(*object.Integer)
+		if aok && bok {
+			return a.Value < b.Value
+		}
+		return toFloat(elements[i]).(*object.Float).Value < toFloat(elements[j]).(*object.Float).Value
+	})
+	return &object.Array{Elements: elements}
+}
+
+// reports whether obj can be handed to applyFunction, builtins taking a
+// callback check this before calling back into the evaluator
+func isCallable(obj object.Object) bool {
+	switch obj.(type) {
+	case *object.Function, *object.Builtin, *object.Memoized:
+		return true
+	default:
+		return false
+	}
+}
+
+// returns a new array holding fn applied to every element of arr
+func mapArray(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+	arr, ok := args[0].(*object.Array)
+	if !ok {
+		return newError("first argument to `map` must be ARRAY, got %s", args[0].Type())
+	}
+	if !isCallable(args[1]) {
+		return newError("second argument to `map` must be FUNCTION, got %s", args[1].Type())
+	}
+
+	results := make([]object.Object, len(arr.Elements))
+	for i, el := range arr.Elements {
+		res := applyFunction(args[1], []object.Object{el})
+		if isError(res) {
+			return res
+		}
+		results[i] = res
+	}
+	return &object.Array{Elements: results}
+}
+
+// makes fn callable from scripts as name, replacing any builtin already there
+func RegisterBuiltin(name string, fn object.BuiltinFunction) {
+	builtins[name] = &object.Builtin{Fn: fn}
+}
+
+// returns the sorted names of every builtin the config lets scripts see
+func builtinNames(args ...object.Object) object.Object {
+	if len(args) != 0 {
+		return newError("wrong number of arguments. got=%d, want=0", len(args))
+	}
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		if _, ok := lookupBuiltin(name); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	elements := make([]object.Object, len(names))
+	for i, name := range names {
+		elements[i] = &object.String{Value: name}
+	}
+	return &object.Array{Elements: elements}
+}
+
+// calls fn with no arguments and returns [result, elapsed milliseconds]
+func measure(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+	if !isCallable(args[0]) {
+		return newError("argument to `measure` must be FUNCTION, got %s", args[0].Type())
+	}
+
+	start := now()
+	res := applyFunction(args[0], []object.Object{})
+	if isError(res) {
+		return res
+	}
+	elapsed := now().Sub(start)
+	millis := &object.Float{Value: float64(elapsed) / float64(time.Millisecond)}
+	return &object.Array{Elements: []object.Object{res, millis}}
+}
+
+// calls fn with every index from 0 to n-1 and collects the results
+func times(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+	n, ok := args[0].(*object.Integer)
+	if !ok {
+		return newError("first argument to `times` must be INTEGER, got %s", args[0].Type())
+	}
+	if !isCallable(args[1]) {
+		return newError("second argument to `times` must be FUNCTION, got %s", args[1].Type())
+	}
+
+	results := []object.Object{}
+	for i := int64(0); i < n.Value; i++ {
+		res := applyFunction(args[1], []object.Object{&object.Integer{Value: i}})
+		if isError(res) {
+			return res
+		}
+		results = append(results, res)
+	}
+	return &object.Array{Elements: results}
+}
+
+// runs fn with a yield callback and returns everything it yielded
+func generator(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+	if _, ok := args[0].(*object.Function); !ok {
+		return newError("argument to `generator` must be FUNCTION, got %s", args[0].Type())
+	}
+
+	yielded := []object.Object{}
+	yield := &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			yielded = append(yielded, args[0])
+			return NULL
+		},
+	}
+
+	res := applyFunction(args[0], []object.Object{yield})
+	if isError(res) {
+		return res
+	}
+	return &object.Array{Elements: yielded}
+}
+
+// reports whether a and b are the same type and structurally equal
+func objectsEqual(a, b object.Object) bool {
+	return objectsDiff(a, b, "") == ""
+}
+
+// describes the first place where actual differs from expected, or returns
+// "" when they are deeply equal, path is the index trail walked so far
+func objectsDiff(actual, expected object.Object, path string) string {
+	return containersDiff(actual, expected, path, map[[2]object.Object]bool{})
+}
+
+// objectsDiff with the array and hash pairs already being compared, meeting
+// a pair again means both sides loop back at the same point so it counts as equal
+func containersDiff(actual, expected object.Object, path string, seen map[[2]object.Object]bool) string {
+	at := ""
+	if path != "" {
+		at = "at " + path + ": "
+	}
+	if actual.Type() != expected.Type() {
+		return fmt.Sprintf("%sexpected %s %s, got %s %s", at,
+			expected.Type(), describe(expected), actual.Type(), describe(actual))
+	}
+	switch expected.(type) {
+	case *object.Array, *object.Hash:
+		pair := [2]object.Object{actual, expected}
+		if seen[pair] {
+			return ""
+		}
+		seen[pair] = true
+	}
+	switch expected := expected.(type) {
+	case *object.Array:
+		actual := actual.(*object.Array)
+		if len(actual.Elements) != len(expected.Elements) {
+			return fmt.Sprintf("%sexpected ARRAY of length %d, got length %d",
+				at, len(expected.Elements), len(actual.Elements))
+		}
+		for i := range expected.Elements {
+			if diff := containersDiff(actual.Elements[i], expected.Elements[i], fmt.Sprintf("%s[%d]", path, i), seen); diff != "" {
+				return diff
+			}
+		}
+		return ""
+	case *object.Hash:
+		actual := actual.(*object.Hash)
+		for _, pair := range sortedPairs(expected) {
+			key := pair.Key.(object.Hashable).HashKey()
+			got, ok := actual.Pairs[key]
+			if !ok {
+				return fmt.Sprintf("%smissing key %s", at, describe(pair.Key))
+			}
+			if diff := containersDiff(got.Value, pair.Value, path+"["+describe(pair.Key)+"]", seen); diff != "" {
+				return diff
+			}
+		}
+		for _, pair := range sortedPairs(actual) {
+			if _, ok := expected.Pairs[pair.Key.(object.Hashable).HashKey()]; !ok {
+				return fmt.Sprintf("%sunexpected key %s", at, describe(pair.Key))
+			}
+		}
+		return ""
+	case *object.Integer:
+		if actual.(*object.Integer).Value == expected.Value {
+			return ""
+		}
+	case *object.Float:
+		if actual.(*object.Float).Value == expected.Value {
+			return ""
+		}
+	case *object.Boolean:
+		if actual.(*object.Boolean).Value == expected.Value {
+			return ""
+		}
+	case *object.String:
+		if actual.(*object.String).Value == expected.Value {
+			return ""
+		}
+	case *object.Null:
+		return ""
+	default:
+		// functions and builtins are only equal to themselves
+		if actual == expected {
+			return ""
+		}
+	}
+	return fmt.Sprintf("%sexpected %s, got %s", at, describe(expected), describe(actual))
+}
+
+// formats obj for error messages, strings are quoted so "1" and 1 differ
+func describe(obj object.Object) string {
+	if str, ok := obj.(*object.String); ok {
+		return str.Quoted()
+	}
+	return obj.Inspect()
+}
+
+// returns the [key, value] pairs of a hash argument in sorted key order,
+// keys are the objects stored in the hash so they keep their type
+func hashEntries(name string, args []object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+	hash, ok := args[0].(*object.Hash)
+	if !ok {
+		return newError("argument to `%s` must be HASH, got %s", name, args[0].Type())
+	}
+	pairs := sortedPairs(hash)
+	entries := make([]object.Object, len(pairs))
+	for i, pair := range pairs {
+		entries[i] = &object.Array{Elements: []object.Object{pair.Key, pair.Value}}
+	}
+	return &object.Array{Elements: entries}
+}
+
+// returns the keys, or the values when keys is false, of a hash argument.
+// both come in sorted key order so keys(h)[i] goes with values(h)[i]
+func hashColumn(name string, args []object.Object, keys bool) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+	hash, ok := args[0].(*object.Hash)
+	if !ok {
+		return newError("argument to `%s` must be HASH, got %s", name, args[0].Type())
+	}
+	pairs := sortedPairs(hash)
+	elements := make([]object.Object, len(pairs))
+	for i, pair := range pairs {
+		if keys {
+			elements[i] = pair.Key
+		} else {
+			elements[i] = pair.Value
+		}
+	}
+	return &object.Array{Elements: elements}
+}
+
+// returns the smallest argument when smallest is true and the largest
+// otherwise. if any argument is a float the result is a float too
+func extremum(name string, args []object.Object, smallest bool) object.Object {
+	if len(args) == 0 {
+		return newError("`%s` needs at least 1 argument", name)
+	}
+	hasFloat := false
+	for _, arg := range args {
+		if !isNumber(arg) {
+			return newError("arguments to `%s` must be INTEGER or FLOAT, got %s", name, arg.Type())
+		}
+		hasFloat = hasFloat || arg.Type() == object.FLOAT_OBJ
+	}
+
+	if hasFloat {
+		best := toFloat(args[0]).(*object.Float)
+		for _, arg := range args[1:] {
+			f := toFloat(arg).(*object.Float)
+			if (f.Value < best.Value) == smallest && f.Value != best.Value {
+				best = f
+			}
+		}
+		return best
+	}
+	best := args[0].(*object.Integer)
+	for _, arg := range args[1:] {
+		i := arg.(*object.Integer)
+		if (i.Value < best.Value) == smallest && i.Value != best.Value {
+			best = i
+		}
+	}
+	return best
+}
+
+// returns the pairs of hash ordered by the inspected key
+func sortedPairs(hash *object.Hash) []object.HashPair {
+	pairs := make([]object.HashPair, 0, len(hash.Pairs))
+	for _, pair := range hash.Pairs {
+		pairs = append(pairs, pair)
+	}
+	// keys of different types can print the same, so break ties on the type
+	sort.Slice(pairs, func(i, j int) bool {
+		ki, kj := pairs[i].Key.Inspect(), pairs[j].Key.Inspect()
+		if ki != kj {
+			return ki < kj
+		}
+		return pairs[i].Key.Type() < pairs[j].Key.Type()
+	})
+	return pairs
+}
+
+// recursively merges b into a copy of a, nested hashes are merged and
+// any other value in b replaces the one in a
+func deepMergeHashes(a, b *object.Hash) *object.Hash {
+	pairs := make(map[object.HashKey]object.HashPair, len(a.Pairs)+len(b.Pairs))
+	for key, pair := range a.Pairs {
+		pairs[key] = pair
+	}
+	for key, pair := range b.Pairs {
+		existing, found := pairs[key]
+		if !found {
+			pairs[key] = pair
+			continue
+		}
+		left, leftOk := existing.Value.(*object.Hash)
+		right, rightOk := pair.Value.(*object.Hash)
+		if leftOk && rightOk {
+			pairs[key] = object.HashPair{Key: existing.Key, Value: deepMergeHashes(left, right)}
+		} else {
+			pairs[key] = object.HashPair{Key: existing.Key, Value: pair.Value}
+		}
+	}
+	return &object.Hash{Pairs: pairs}
+}
+
+// builds a new hash from the pairs of hash whose presence in keys equals keep
+func filterHashKeys(name string, args []object.Object, keep bool) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+	hash, ok := args[0].(*object.Hash)
+	if !ok {
+		return newError("first argument to `%s` must be HASH, got %s", name, args[0].Type())
+	}
+	keys, ok := args[1].(*object.Array)
+	if !ok {
+		return newError("second argument to `%s` must be ARRAY, got %s", name, args[1].Type())
+	}
+	listed := make(map[object.HashKey]bool, len(keys.Elements))
+	for _, key := range keys.Elements {
+		hashable, ok := key.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", key.Type())
+		}
+		listed[hashable.HashKey()] = true
+	}
+
+	pairs := make(map[object.HashKey]object.HashPair)
+	for key, pair := range hash.Pairs {
+		if listed[key] == keep {
+			pairs[key] = pair
+		}
+	}
+	return &object.Hash{Pairs: pairs}
+}
+
+// returns a copy of coll with value stored at path, only the collections
+// along the path are copied and missing steps become new hashes
+func setIn(coll object.Object, path []object.Object, value object.Object) object.Object {
+	if len(path) == 0 {
+		return value
+	}
+	step := path[0]
+	switch coll := coll.(type) {
+	case nil, *object.Null:
+		hashable, ok := step.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", step.Type())
+		}
+		child := setIn(nil, path[1:], value)
+		if isError(child) {
+			return child
+		}
+		pairs := map[object.HashKey]object.HashPair{
+			hashable.HashKey(): {Key: step, Value: child},
+		}
+		return &object.Hash{Pairs: pairs}
+	case *object.Hash:
+		hashable, ok := step.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", step.Type())
+		}
+		pairs := make(map[object.HashKey]object.HashPair, len(coll.Pairs)+1)
+		for k, v := range coll.Pairs {
+			pairs[k] = v
+		}
+		key := hashable.HashKey()
+		pair, found := pairs[key]
+		if !found {
+			pair = object.HashPair{Key: step}
+		}
+		child := setIn(pair.Value, path[1:], value)
+		if isError(child) {
+			return child
+		}
+		pairs[key] = object.HashPair{Key: pair.Key, Value: child}
+		return &object.Hash{Pairs: pairs}
+	case *object.Array:
+		idx, ok := step.(*object.Integer)
+		if !ok {
+			return newError("cannot index ARRAY with %s", step.Type())
+		}
+		if idx.Value < 0 || idx.Value >= int64(len(coll.Elements)) {
+			return newError("index out of range: %d", idx.Value)
+		}
+		child := setIn(coll.Elements[idx.Value], path[1:], value)
+		if isError(child) {
+			return child
+		}
+		elements := make([]object.Object, len(coll.Elements))
+		copy(elements, coll.Elements)
+		elements[idx.Value] = child
+		return &object.Array{Elements: elements}
+	default:
+		return newError("cannot set %s inside %s", step.Inspect(), coll.Type())
+	}
+}
+
+// marks obj and every array or hash nested inside it as frozen. slice
+// assignment is the only operation that changes a container in place and it
+// refuses frozen arrays, builtins like push, delete and setIn return copies
+// and work on frozen values as on any other
+func freezeDeep(obj object.Object) {
+	switch obj := obj.(type) {
+	case *object.Array:
+		if obj.Frozen {
+			return
+		}
+		obj.Frozen = true
+		for _, el := range obj.Elements {
+			freezeDeep(el)
+		}
+	case *object.Hash:
+		if obj.Frozen {
+			return
+		}
+		obj.Frozen = true
+		for _, pair := range obj.Pairs {
+			freezeDeep(pair.Value)
+		}
+	}
+}
+
 var builtins = map[string]*object.Builtin{
 	"len": &object.Builtin{
 		Fn: func(args ...object.Object) object.Object {
@@ -84,19 +732,807 @@ var builtins = map[string]*object.Builtin{
 			}
 			switch arg := args[0].(type) {
 			case *object.Array:
-				return &object.Array{Elements: append(arg.Elements, args[1])}
+				// copy so arrays pushed from the same source never share storage
+				elements := make([]object.Object, len(arg.Elements), len(arg.Elements)+1)
+				copy(elements, arg.Elements)
+				return &object.Array{Elements: append(elements, args[1])}
 			default:
 				return newError("argument to `push` must be ARRAY, got %s", args[0].Type())
 			}
 
 		},
 	},
+	"reverse": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			switch arg := args[0].(type) {
+			case *object.Array:
+				n := len(arg.Elements)
+				elements := make([]object.Object, n)
+				for i, el := range arg.Elements {
+					elements[n-1-i] = el
+				}
+				return &object.Array{Elements: elements}
+			case *object.String:
+				// reverse by rune so multi-byte characters stay intact
+				runes := []rune(arg.Value)
+				for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+					runes[i], runes[j] = runes[j], runes[i]
+				}
+				return &object.String{Value: string(runes)}
+			default:
+				return newError("argument to `reverse` must be ARRAY or STRING, got %s", args[0].Type())
+			}
+		},
+	},
 	"puts": &object.Builtin{
 		Fn: func(args ...object.Object) object.Object {
 			for _, arg := range args {
-				fmt.Println(arg.Inspect())
+				fmt.Fprintln(ioContext.Out, arg.Inspect())
+			}
+			return NULL
+		},
+	},
+	// print writes its arguments back to back, with no separator and no
+	// trailing newline, so output can be built up a piece at a time
+	"print": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			for _, arg := range args {
+				fmt.Fprint(ioContext.Out, arg.Inspect())
+			}
+			return NULL
+		},
+	},
+	"read": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if ioContext.FS == nil {
+				return newError("builtin `read` is disabled")
+			}
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			path, ok := args[0].(*object.String)
+			if !ok {
+				return newError("argument to `read` must be STRING, got %s", args[0].Type())
+			}
+			data, err := ioContext.FS.ReadFile(path.Value)
+			if err != nil {
+				return newError("could not read %s: %s", path.Value, err)
+			}
+			return &object.String{Value: string(data)}
+		},
+	},
+	"write": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if ioContext.FS == nil {
+				return newError("builtin `write` is disabled")
+			}
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+			path, ok := args[0].(*object.String)
+			if !ok {
+				return newError("first argument to `write` must be STRING, got %s", args[0].Type())
+			}
+			content, ok := args[1].(*object.String)
+			if !ok {
+				return newError("second argument to `write` must be STRING, got %s", args[1].Type())
+			}
+			if err := ioContext.FS.WriteFile(path.Value, []byte(content.Value)); err != nil {
+				return newError("could not write %s: %s", path.Value, err)
+			}
+			return NULL
+		},
+	},
+	"getenv": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			name, ok := args[0].(*object.String)
+			if !ok {
+				return newError("argument to `getenv` must be STRING, got %s", args[0].Type())
+			}
+			val, ok := os.LookupEnv(name.Value)
+			if !ok {
+				return NULL
+			}
+			return &object.String{Value: val}
+		},
+	},
+	"setenv": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+			name, ok := args[0].(*object.String)
+			if !ok {
+				return newError("first argument to `setenv` must be STRING, got %s", args[0].Type())
+			}
+			val, ok := args[1].(*object.String)
+			if !ok {
+				return newError("second argument to `setenv` must be STRING, got %s", args[1].Type())
+			}
+			if err := os.Setenv(name.Value, val.Value); err != nil {
+				return newError("could not set %s: %s", name.Value, err)
+			}
+			return NULL
+		},
+	},
+	"args": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return newError("wrong number of arguments. got=%d, want=0", len(args))
+			}
+			elements := make([]object.Object, 0, len(ScriptArgs))
+			for _, a := range ScriptArgs {
+				elements = append(elements, &object.String{Value: a})
+			}
+			return &object.Array{Elements: elements}
+		},
+	},
+	"input": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) > 1 {
+				return newError("wrong number of arguments. got=%d, want=0 or 1", len(args))
+			}
+			if len(args) == 1 {
+				prompt, ok := args[0].(*object.String)
+				if !ok {
+					return newError("argument to `input` must be STRING, got %s", args[0].Type())
+				}
+				fmt.Fprint(ioContext.Out, prompt.Value)
+			}
+			line, err := inputReader.ReadString('\n')
+			if err != nil && line == "" {
+				if err == io.EOF {
+					return NULL
+				}
+				return newError("could not read input: %s", err)
+			}
+			line = strings.TrimSuffix(line, "\n")
+			line = strings.TrimSuffix(line, "\r")
+			return &object.String{Value: line}
+		},
+	},
+	"lines": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return newError("wrong number of arguments. got=%d, want=0", len(args))
+			}
+			elements := []object.Object{}
+			scanner := bufio.NewScanner(inputReader)
+			for scanner.Scan() {
+				line := strings.TrimSuffix(scanner.Text(), "\r")
+				elements = append(elements, &object.String{Value: line})
+			}
+			if err := scanner.Err(); err != nil {
+				return newError("could not read input: %s", err)
+			}
+			return &object.Array{Elements: elements}
+		},
+	},
+	// render(template, hash) replaces {name} with the value stored under "name",
+	// placeholders with no matching key are left in the output untouched
+	"render": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+			tmpl, ok := args[0].(*object.String)
+			if !ok {
+				return newError("first argument to `render` must be STRING, got %s", args[0].Type())
+			}
+			hash, ok := args[1].(*object.Hash)
+			if !ok {
+				return newError("second argument to `render` must be HASH, got %s", args[1].Type())
+			}
+			values := make(map[string]string, len(hash.Pairs))
+			for _, pair := range hash.Pairs {
+				str, ok := pair.Key.(*object.String)
+				if !ok {
+					return newError("keys passed to `render` must be STRING, got %s", pair.Key.Type())
+				}
+				values[str.Value] = pair.Value.Inspect()
+			}
+
+			var out strings.Builder
+			rest := tmpl.Value
+			for {
+				start := strings.IndexByte(rest, '{')
+				if start < 0 {
+					break
+				}
+				end := strings.IndexByte(rest[start:], '}')
+				if end < 0 {
+					break
+				}
+				end += start
+				out.WriteString(rest[:start])
+				if val, ok := values[rest[start+1:end]]; ok {
+					out.WriteString(val)
+				} else {
+					out.WriteString(rest[start : end+1])
+				}
+				rest = rest[end+1:]
+			}
+			out.WriteString(rest)
+			return &object.String{Value: out.String()}
+		},
+	},
+	"base64Encode": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			str, ok := args[0].(*object.String)
+			if !ok {
+				return newError("argument to `base64Encode` must be STRING, got %s", args[0].Type())
+			}
+			return &object.String{Value: base64.StdEncoding.EncodeToString([]byte(str.Value))}
+		},
+	},
+	"base64Decode": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			str, ok := args[0].(*object.String)
+			if !ok {
+				return newError("argument to `base64Decode` must be STRING, got %s", args[0].Type())
+			}
+			decoded, err := base64.StdEncoding.DecodeString(str.Value)
+			if err != nil {
+				return newError("could not decode base64: %s", err)
+			}
+			return &object.String{Value: string(decoded)}
+		},
+	},
+	"sha256": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			str, ok := args[0].(*object.String)
+			if !ok {
+				return newError("argument to `sha256` must be STRING, got %s", args[0].Type())
+			}
+			sum := sha256.Sum256([]byte(str.Value))
+			return &object.String{Value: hex.EncodeToString(sum[:])}
+		},
+	},
+	"md5": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			str, ok := args[0].(*object.String)
+			if !ok {
+				return newError("argument to `md5` must be STRING, got %s", args[0].Type())
+			}
+			sum := md5.Sum([]byte(str.Value))
+			return &object.String{Value: hex.EncodeToString(sum[:])}
+		},
+	},
+	"uuid": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return newError("wrong number of arguments. got=%d, want=0", len(args))
+			}
+			var b [16]byte
+			random.Read(b[:])
+			b[6] = b[6]&0x0f | 0x40 // version 4
+			b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+			uuid := fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+			return &object.String{Value: uuid}
+		},
+	},
+	"deepMerge": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+			a, ok := args[0].(*object.Hash)
+			if !ok {
+				return newError("first argument to `deepMerge` must be HASH, got %s", args[0].Type())
+			}
+			b, ok := args[1].(*object.Hash)
+			if !ok {
+				return newError("second argument to `deepMerge` must be HASH, got %s", args[1].Type())
+			}
+			return deepMergeHashes(a, b)
+		},
+	},
+	"pick": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			return filterHashKeys("pick", args, true)
+		},
+	},
+	"omit": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			return filterHashKeys("omit", args, false)
+		},
+	},
+	"getIn": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+			path, ok := args[1].(*object.Array)
+			if !ok {
+				return newError("second argument to `getIn` must be ARRAY, got %s", args[1].Type())
+			}
+			current := args[0]
+			for _, step := range path.Elements {
+				switch coll := current.(type) {
+				case *object.Hash:
+					hashable, ok := step.(object.Hashable)
+					if !ok {
+						return NULL
+					}
+					pair, found := coll.Pairs[hashable.HashKey()]
+					if !found {
+						return NULL
+					}
+					current = pair.Value
+				case *object.Array:
+					idx, ok := step.(*object.Integer)
+					if !ok || idx.Value < 0 || idx.Value >= int64(len(coll.Elements)) {
+						return NULL
+					}
+					current = coll.Elements[idx.Value]
+				default:
+					return NULL
+				}
+			}
+			return current
+		},
+	},
+	"setIn": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 3 {
+				return newError("wrong number of arguments. got=%d, want=3", len(args))
+			}
+			path, ok := args[1].(*object.Array)
+			if !ok {
+				return newError("second argument to `setIn` must be ARRAY, got %s", args[1].Type())
+			}
+			return setIn(args[0], path.Elements, args[2])
+		},
+	},
+	"int": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			switch arg := args[0].(type) {
+			case *object.Integer:
+				return arg
+			case *object.Float:
+				if math.IsNaN(arg.Value) || arg.Value >= math.MaxInt64 || arg.Value < math.MinInt64 {
+					return newError("cannot convert %s to INTEGER", arg.Inspect())
+				}
+				return newInteger(int64(arg.Value))
+			case *object.String:
+				val, err := strconv.ParseInt(arg.Value, 10, 64)
+				if err != nil {
+					return newError("cannot convert %q to INTEGER", arg.Value)
+				}
+				return newInteger(val)
+			case *object.Boolean:
+				if arg.Value {
+					return &object.Integer{Value: 1}
+				}
+				return &object.Integer{Value: 0}
+			default:
+				return newError("argument to `int` not supported, got %s", args[0].Type())
+			}
+		},
+	},
+	"str": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			if str, ok := args[0].(*object.String); ok {
+				return str
+			}
+			return &object.String{Value: args[0].Inspect()}
+		},
+	},
+	"bool": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			// unlike conditions, which only treat false and null as false,
+			// converting also turns zero and empty values false
+			switch arg := args[0].(type) {
+			case *object.Integer:
+				return nativeBoolObject(arg.Value != 0)
+			case *object.Float:
+				return nativeBoolObject(arg.Value != 0)
+			case *object.String:
+				return nativeBoolObject(arg.Value != "")
+			case *object.Array:
+				return nativeBoolObject(len(arg.Elements) != 0)
+			case *object.Hash:
+				return nativeBoolObject(len(arg.Pairs) != 0)
+			default:
+				return nativeBoolObject(isTruthy(arg))
+			}
+		},
+	},
+	"split": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+			str, ok := args[0].(*object.String)
+			if !ok {
+				return newError("first argument to `split` must be STRING, got %s", args[0].Type())
+			}
+			sep, ok := args[1].(*object.String)
+			if !ok {
+				return newError("second argument to `split` must be STRING, got %s", args[1].Type())
+			}
+			// an empty separator splits between every character
+			pieces := strings.Split(str.Value, sep.Value)
+			elements := make([]object.Object, len(pieces))
+			for i, piece := range pieces {
+				elements[i] = &object.String{Value: piece}
+			}
+			return &object.Array{Elements: elements}
+		},
+	},
+	"join": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return newError("first argument to `join` must be ARRAY, got %s", args[0].Type())
+			}
+			sep, ok := args[1].(*object.String)
+			if !ok {
+				return newError("second argument to `join` must be STRING, got %s", args[1].Type())
+			}
+			// elements that are not strings are written as they inspect
+			pieces := make([]string, len(arr.Elements))
+			for i, el := range arr.Elements {
+				pieces[i] = el.Inspect()
+			}
+			return &object.String{Value: strings.Join(pieces, sep.Value)}
+		},
+	},
+	"range": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) < 1 || len(args) > 3 {
+				return newError("wrong number of arguments. got=%d, want=1 to 3", len(args))
+			}
+			bounds := make([]int64, len(args))
+			for i, arg := range args {
+				n, ok := arg.(*object.Integer)
+				if !ok {
+					return newError("arguments to `range` must be INTEGER, got %s", arg.Type())
+				}
+				bounds[i] = n.Value
+			}
+			// range(end), range(start, end) or range(start, end, step)
+			start, end, step := int64(0), bounds[0], int64(1)
+			if len(bounds) > 1 {
+				start, end = bounds[0], bounds[1]
+			}
+			if len(bounds) == 3 {
+				step = bounds[2]
+			}
+			if step == 0 {
+				return newError("step of `range` must not be 0")
+			}
+			elements := []object.Object{}
+			for i := start; (step > 0 && i < end) || (step < 0 && i > end); i += step {
+				elements = append(elements, integerObject(i))
+			}
+			return &object.Array{Elements: elements}
+		},
+	},
+	"parseInt": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+			str, ok := args[0].(*object.String)
+			if !ok {
+				return newError("first argument to `parseInt` must be STRING, got %s", args[0].Type())
+			}
+			base, ok := args[1].(*object.Integer)
+			if !ok {
+				return newError("second argument to `parseInt` must be INTEGER, got %s", args[1].Type())
+			}
+			if base.Value < 2 || base.Value > 36 {
+				return newError("base must be between 2 and 36, got %d", base.Value)
+			}
+			val, err := strconv.ParseInt(str.Value, int(base.Value), 64)
+			if err != nil {
+				return newError("could not parse %q as base %d integer", str.Value, base.Value)
+			}
+			return newInteger(val)
+		},
+	},
+	"toBase": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+			n, ok := args[0].(*object.Integer)
+			if !ok {
+				return newError("first argument to `toBase` must be INTEGER, got %s", args[0].Type())
+			}
+			base, ok := args[1].(*object.Integer)
+			if !ok {
+				return newError("second argument to `toBase` must be INTEGER, got %s", args[1].Type())
+			}
+			if base.Value < 2 || base.Value > 36 {
+				return newError("base must be between 2 and 36, got %d", base.Value)
+			}
+			return &object.String{Value: strconv.FormatInt(n.Value, int(base.Value))}
+		},
+	},
+	"sign": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			var v float64
+			switch arg := args[0].(type) {
+			case *object.Integer:
+				v = float64(arg.Value)
+			case *object.Float:
+				v = arg.Value
+			default:
+				return newError("argument to `sign` must be INTEGER or FLOAT, got %s", args[0].Type())
+			}
+			switch {
+			case v < 0:
+				return &object.Integer{Value: -1}
+			case v > 0:
+				return &object.Integer{Value: 1}
+			default:
+				return &object.Integer{Value: 0}
+			}
+		},
+	},
+	"abs": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			switch arg := args[0].(type) {
+			case *object.Integer:
+				if arg.Value == math.MinInt64 {
+					return newError("integer overflow: abs(%d) does not fit in 64 bits", arg.Value)
+				}
+				if arg.Value < 0 {
+					return newInteger(-arg.Value)
+				}
+				return arg
+			case *object.Float:
+				return &object.Float{Value: math.Abs(arg.Value)}
+			default:
+				return newError("argument to `abs` must be INTEGER or FLOAT, got %s", args[0].Type())
+			}
+		},
+	},
+	"min": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			return extremum("min", args, true)
+		},
+	},
+	"max": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			return extremum("max", args, false)
+		},
+	},
+	// freezeDeep(x) freezes x and everything inside it, see freezeDeep for what that guards
+	"freezeDeep": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			switch args[0].(type) {
+			case *object.Array, *object.Hash:
+				freezeDeep(args[0])
+				return args[0]
+			default:
+				return newError("argument to `freezeDeep` must be ARRAY or HASH, got %s", args[0].Type())
+			}
+		},
+	},
+	"source": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			fn, ok := args[0].(*object.Function)
+			if !ok {
+				return newError("argument to `source` must be FUNCTION, got %s", args[0].Type())
+			}
+			return &object.String{Value: fn.Inspect()}
+		},
+	},
+	"type": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			return &object.String{Value: string(args[0].Type())}
+		},
+	},
+	"assertEqual": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+			if diff := objectsDiff(args[0], args[1], ""); diff != "" {
+				return newError("assertEqual failed: %s", diff)
 			}
 			return NULL
 		},
 	},
+	"zipHash": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+			keys, ok := args[0].(*object.Array)
+			if !ok {
+				return newError("first argument to `zipHash` must be ARRAY, got %s", args[0].Type())
+			}
+			values, ok := args[1].(*object.Array)
+			if !ok {
+				return newError("second argument to `zipHash` must be ARRAY, got %s", args[1].Type())
+			}
+			// extra keys or values beyond the shorter array are dropped
+			n := min(len(keys.Elements), len(values.Elements))
+			pairs := make(map[object.HashKey]object.HashPair, n)
+			for i := 0; i < n; i++ {
+				key := keys.Elements[i]
+				hashable, ok := key.(object.Hashable)
+				if !ok {
+					return newError("unusable as hash key: %s", key.Type())
+				}
+				pairs[hashable.HashKey()] = object.HashPair{Key: key, Value: values.Elements[i]}
+			}
+			return &object.Hash{Pairs: pairs}
+		},
+	},
+	"invert": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			hash, ok := args[0].(*object.Hash)
+			if !ok {
+				return newError("argument to `invert` must be HASH, got %s", args[0].Type())
+			}
+			// walking in sorted key order means the last key wins on a repeated value
+			inverted := make(map[object.HashKey]object.HashPair, len(hash.Pairs))
+			for _, pair := range sortedPairs(hash) {
+				hashable, ok := pair.Value.(object.Hashable)
+				if !ok {
+					return newError("unusable as hash key: %s", pair.Value.Type())
+				}
+				inverted[hashable.HashKey()] = object.HashPair{Key: pair.Value, Value: pair.Key}
+			}
+			return &object.Hash{Pairs: inverted}
+		},
+	},
+	"delete": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+			hash, ok := args[0].(*object.Hash)
+			if !ok {
+				return newError("first argument to `delete` must be HASH, got %s", args[0].Type())
+			}
+			hashable, ok := args[1].(object.Hashable)
+			if !ok {
+				return newError("unusable as hash key: %s", args[1].Type())
+			}
+			// like push, the argument is left alone and a copy is returned
+			removed := hashable.HashKey()
+			pairs := make(map[object.HashKey]object.HashPair, len(hash.Pairs))
+			for key, pair := range hash.Pairs {
+				if key != removed {
+					pairs[key] = pair
+				}
+			}
+			return &object.Hash{Pairs: pairs}
+		},
+	},
+	"contains": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+			switch coll := args[0].(type) {
+			case *object.Array:
+				for _, el := range coll.Elements {
+					if objectsEqual(el, args[1]) {
+						return TRUE
+					}
+				}
+				return FALSE
+			case *object.Hash:
+				hashable, ok := args[1].(object.Hashable)
+				if !ok {
+					return newError("unusable as hash key: %s", args[1].Type())
+				}
+				_, ok = coll.Pairs[hashable.HashKey()]
+				return nativeBoolObject(ok)
+			default:
+				return newError("first argument to `contains` must be ARRAY or HASH, got %s", args[0].Type())
+			}
+		},
+	},
+	"memoize": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			if !isCallable(args[0]) {
+				return newError("argument to `memoize` must be FUNCTION, got %s", args[0].Type())
+			}
+			return &object.Memoized{Fn: args[0], Cache: map[string]object.Object{}}
+		},
+	},
+	"cacheSize": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			memo, ok := args[0].(*object.Memoized)
+			if !ok {
+				return newError("argument to `cacheSize` must be MEMOIZED, got %s", args[0].Type())
+			}
+			return integerObject(int64(memo.CacheSize()))
+		},
+	},
+	"cacheClear": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			memo, ok := args[0].(*object.Memoized)
+			if !ok {
+				return newError("argument to `cacheClear` must be MEMOIZED, got %s", args[0].Type())
+			}
+			memo.ClearCache()
+			return NULL
+		},
+	},
+	"keys": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			return hashColumn("keys", args, true)
+		},
+	},
+	"values": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			return hashColumn("values", args, false)
+		},
+	},
+	"sortedEntries": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			return hashEntries("sortedEntries", args)
+		},
+	},
+	"pairs": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			return hashEntries("pairs", args)
+		},
+	},
 }
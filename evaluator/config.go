@@ -0,0 +1,99 @@
+package evaluator
+
+import (
+	"interpreter/object"
+	"math"
+)
+
+// optional evaluator semantics, hosts may adjust these before calling Eval
+type Config struct {
+	// width of integer values, either 64 (default) or 32
+	IntegerBits int
+	// in 32 bit mode wrap results that overflow instead of returning an error
+	WrapOverflow bool
+	// let + join a string with a number by converting the number, otherwise a type mismatch
+	CoerceStringConcat bool
+	// out of range array and string indexes return an error instead of null
+	StrictIndexing bool
+	// builtins scripts cannot see, resolving them fails as if they were never defined
+	DisabledBuiltins []string
+	// when not empty only these builtins can be seen, for sandboxing untrusted scripts
+	AllowedBuiltins []string
+}
+
+func DefaultConfig() Config {
+	return Config{IntegerBits: 64}
+}
+
+var config = DefaultConfig()
+
+func SetConfig(c Config) {
+	config = c
+}
+
+func GetConfig() Config {
+	return config
+}
+
+// finds the builtin called name unless the config hides it
+func lookupBuiltin(name string) (*object.Builtin, bool) {
+	if len(config.AllowedBuiltins) > 0 && !containsName(config.AllowedBuiltins, name) {
+		return nil, false
+	}
+	if containsName(config.DisabledBuiltins, name) {
+		return nil, false
+	}
+	b, ok := builtins[name]
+	return b, ok
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// result of indexing past either end of a collection of length n
+func indexOutOfRange(idx int64, n int) object.Object {
+	if config.StrictIndexing {
+		return newError("index out of range: %d (len %d)", idx, n)
+	}
+	return NULL
+}
+
+// narrows an integer result to the configured width
+func newInteger(v int64) object.Object {
+	if config.IntegerBits != 32 || (v >= math.MinInt32 && v <= math.MaxInt32) {
+		return integerObject(v)
+	}
+	if config.WrapOverflow {
+		return integerObject(int64(int32(v)))
+	}
+	return newError("integer overflow: %d does not fit in 32 bits", v)
+}
+
+// range of integers shared instead of allocated, like TRUE, FALSE and NULL
+const (
+	minCachedInteger = -128
+	maxCachedInteger = 255
+)
+
+// integers are never modified once made, so one object per value can be shared
+var cachedIntegers [maxCachedInteger - minCachedInteger + 1]*object.Integer
+
+func init() {
+	for i := range cachedIntegers {
+		cachedIntegers[i] = &object.Integer{Value: int64(i + minCachedInteger)}
+	}
+}
+
+// returns the shared object for small values and a new one otherwise
+func integerObject(v int64) *object.Integer {
+	if v >= minCachedInteger && v <= maxCachedInteger {
+		return cachedIntegers[v-minCachedInteger]
+	}
+	return &object.Integer{Value: v}
+}
@@ -4,12 +4,18 @@ import (
 	"fmt"
 	"interpreter/ast"
 	"interpreter/object"
+	"interpreter/token"
+	"math"
+	"strings"
 )
 
 var (
 	NULL  = &object.Null{}
 	TRUE  = &object.Boolean{Value: true}
 	FALSE = &object.Boolean{Value: false}
+
+	BREAK    = &object.Break{}
+	CONTINUE = &object.Continue{}
 )
 
 func Eval(node ast.Node, env *object.Enviroment) object.Object {
@@ -22,19 +28,32 @@ func Eval(node ast.Node, env *object.Enviroment) object.Object {
 		return Eval(node.Expression, env)
 
 	case *ast.IntegerLiteral:
-		return &object.Integer{Value: node.Value}
+		return newInteger(node.Value)
+
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
 
 	case *ast.Boolean:
 		return nativeBoolObject(node.Value)
 
+	case *ast.Null:
+		return NULL
+
 	case *ast.PrefixExpression:
+		// negate literals before narrowing so the smallest 32 bit integer can be written
+		if lit, ok := node.Right.(*ast.IntegerLiteral); ok && node.Operator == "-" {
+			return newInteger(-lit.Value)
+		}
 		right := Eval(node.Right, env)
 		if isError(right) {
 			return right
 		}
-		return evalPrefixExpressions(node.Operator, right)
+		return evalPrefixExpressions(node.Operator, right, node.Token)
 
 	case *ast.InfixExpression:
+		if node.Operator == "&&" || node.Operator == "||" {
+			return evalLogicalExpression(node, env)
+		}
 		left := Eval(node.Left, env)
 		if isError(left) {
 			return left
@@ -43,7 +62,7 @@ func Eval(node ast.Node, env *object.Enviroment) object.Object {
 		if isError(right) {
 			return right
 		}
-		return evalInfixExpression(node.Operator, right, left)
+		return evalInfixExpression(node.Operator, right, left, node.Token)
 
 	case *ast.IfExpression:
 		return evalIfExpression(node, env)
@@ -51,6 +70,30 @@ func Eval(node ast.Node, env *object.Enviroment) object.Object {
 	case *ast.BlockStatements:
 		return evalStatements(node.Statements, env)
 
+	case *ast.DoExpression:
+		return evalDoExpression(node, env)
+
+	case *ast.WhileStatement:
+		return evalWhileStatement(node, env)
+
+	case *ast.ForStatement:
+		return evalForStatement(node, env)
+
+	case *ast.ForInStatement:
+		return evalForInStatement(node, env)
+
+	case *ast.BreakStatement:
+		if node.Label != nil {
+			return &object.Break{Label: node.Label.Value}
+		}
+		return BREAK
+
+	case *ast.ContinueStatement:
+		if node.Label != nil {
+			return &object.Continue{Label: node.Label.Value}
+		}
+		return CONTINUE
+
 	case *ast.ReturnStatement:
 		val := Eval(node.ReturnValue, env)
 		if isError(val) {
@@ -59,12 +102,25 @@ func Eval(node ast.Node, env *object.Enviroment) object.Object {
 		return &object.ReturnValue{Value: val}
 
 	case *ast.LetStatement:
+		if _, ok := lookupBuiltin(node.Name.Value); ok {
+			warnAt(node.Name.Token, "let %s shadows the builtin `%s`", node.Name.Value, node.Name.Value)
+		}
 		exp := Eval(node.Value, env)
 		if isError(exp) {
 			return exp
 		}
 		env.Set(node.Name.Value, exp)
 
+	case *ast.AssignStatement:
+		if res := evalAssignStatement(node, env); isError(res) {
+			return res
+		}
+
+	case *ast.SliceAssignStatement:
+		if res := evalSliceAssignStatement(node, env); isError(res) {
+			return res
+		}
+
 	case *ast.Identifier:
 		return evalIdentifier(node, env)
 
@@ -101,6 +157,8 @@ func Eval(node ast.Node, env *object.Enviroment) object.Object {
 			return index
 		}
 		return evalIndexExpression(leftexp, index)
+	case *ast.SliceExpression:
+		return evalSliceExpression(node, env)
 	case *ast.HashExpression:
 		exp := evalHashExpression(node, env)
 		return exp
@@ -120,8 +178,10 @@ func evalIndexExpression(left object.Object, index object.Object) object.Object
 	switch {
 	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
 		return evalArrayIndexExpression(left, index)
+	case left.Type() == object.STRING_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalStringIndexExpression(left, index)
 	case left.Type() == object.HASH_OBJ:
-		return evalArrayHashExpression(left, index)
+		return evalHashIndexExpression(left, index)
 	default:
 		return newError("index operator not supported: %s", left.Type())
 	}
@@ -132,36 +192,139 @@ func evalArrayIndexExpression(array object.Object, index object.Object) object.O
 	idx := index.(*object.Integer).Value
 	max := int64(len(arrayObj.Elements) - 1)
 	if idx < 0 || idx > max {
-		return NULL
+		return indexOutOfRange(idx, len(arrayObj.Elements))
 	}
 
 	return arrayObj.Elements[idx]
 }
 
-func evalArrayHashExpression(hash object.Object, key object.Object) object.Object {
-	HashObj := hash.(*object.Hash)
-	for keyy, val := range HashObj.Pairs {
-		if key.Type() == keyy.Type() {
-			switch keyy.(type) {
-			case *object.Boolean:
-				if keyy.(*object.Boolean).Value == key.(*object.Boolean).Value {
-					return val
-				}
-			case *object.Integer:
-				if keyy.(*object.Integer).Value == key.(*object.Integer).Value {
-					return val
-				}
-			case *object.String:
-				if keyy.(*object.String).Value == key.(*object.String).Value {
-					return val
-				}
+// returns the single character at index, negative indices are out of range like arrays
+func evalStringIndexExpression(str object.Object, index object.Object) object.Object {
+	value := str.(*object.String).Value
+	idx := index.(*object.Integer).Value
+	if idx < 0 || idx >= int64(len(value)) {
+		return indexOutOfRange(idx, len(value))
+	}
 
-			}
-		}
+	return &object.String{Value: value[idx : idx+1]}
+}
 
+func evalSliceExpression(se *ast.SliceExpression, env *object.Enviroment) object.Object {
+	left := Eval(se.LeftExpression, env)
+	if isError(left) {
+		return left
 	}
-	return nil
+
+	var length int64
+	switch left := left.(type) {
+	case *object.Array:
+		length = int64(len(left.Elements))
+	case *object.String:
+		length = int64(len(left.Value))
+	default:
+		return newErrorAt(se.Token, "slice operator not supported: %s", left.Type())
+	}
+
+	start, err := evalSliceBound(se.Start, 0, length, se.Token, env)
+	if err != nil {
+		return err
+	}
+	end, err := evalSliceBound(se.End, length, length, se.Token, env)
+	if err != nil {
+		return err
+	}
+	if start > end {
+		start = end
+	}
+
+	if arr, ok := left.(*object.Array); ok {
+		elements := make([]object.Object, end-start)
+		copy(elements, arr.Elements[start:end])
+		return &object.Array{Elements: elements}
+	}
+	return &object.String{Value: left.(*object.String).Value[start:end]}
+}
+
+// replaces the elements between the bounds with those of the value array,
+// which may be longer or shorter than the range so the array can grow or shrink
+func evalSliceAssignStatement(sa *ast.SliceAssignStatement, env *object.Enviroment) object.Object {
+	se := sa.Target
+	left := Eval(se.LeftExpression, env)
+	if isError(left) {
+		return left
+	}
+	arr, ok := left.(*object.Array)
+	if !ok {
+		return newErrorAt(se.Token, "slice assignment not supported: %s", left.Type())
+	}
+	if arr.Frozen {
+		return newErrorAt(se.Token, "cannot modify frozen ARRAY")
+	}
+
+	length := int64(len(arr.Elements))
+	start, err := evalSliceBound(se.Start, 0, length, se.Token, env)
+	if err != nil {
+		return err
+	}
+	end, err := evalSliceBound(se.End, length, length, se.Token, env)
+	if err != nil {
+		return err
+	}
+	if start > end {
+		start = end
+	}
+
+	val := Eval(sa.Value, env)
+	if isError(val) {
+		return val
+	}
+	replacement, ok := val.(*object.Array)
+	if !ok {
+		return newErrorAt(sa.Token, "slice assignment value must be ARRAY, got %s", val.Type())
+	}
+
+	elements := make([]object.Object, 0, length-(end-start)+int64(len(replacement.Elements)))
+	elements = append(elements, arr.Elements[:start]...)
+	elements = append(elements, replacement.Elements...)
+	elements = append(elements, arr.Elements[end:]...)
+	arr.Elements = elements
+	return arr
 }
+
+// evaluates an optional slice bound, negative bounds count back from the end,
+// then clamps it to [0, length] instead of erroring
+func evalSliceBound(node ast.Expression, def int64, length int64, tok token.Token, env *object.Enviroment) (int64, object.Object) {
+	if node == nil {
+		return def, nil
+	}
+	val := Eval(node, env)
+	if isError(val) {
+		return 0, val
+	}
+	integer, ok := val.(*object.Integer)
+	if !ok {
+		return 0, newErrorAt(tok, "slice bounds must be INTEGER, got %s", val.Type())
+	}
+	bound := integer.Value
+	if bound < 0 {
+		bound += length
+	}
+	return min(max(bound, 0), length), nil
+}
+
+func evalHashIndexExpression(hash object.Object, key object.Object) object.Object {
+	hashObj := hash.(*object.Hash)
+	hashable, ok := key.(object.Hashable)
+	if !ok {
+		return newError("unusable as hash key: %s", key.Type())
+	}
+	pair, ok := hashObj.Pairs[hashable.HashKey()]
+	if !ok {
+		return NULL
+	}
+	return pair.Value
+}
+
 func evalProgram(program *ast.Program, env *object.Enviroment) object.Object {
 	var result object.Object
 	for _, statement := range program.Statements {
@@ -171,119 +334,291 @@ func evalProgram(program *ast.Program, env *object.Enviroment) object.Object {
 			return result.Value
 		case *object.Error:
 			return result
+		case *object.Break, *object.Continue:
+			return loopControlError(result)
 		}
 	}
 
 	return result
 }
 
-func evalPrefixExpressions(op string, val object.Object) object.Object {
+// break and continue that escape every loop end up here
+func loopControlError(obj object.Object) object.Object {
+	switch obj := obj.(type) {
+	case *object.Break:
+		if obj.Label != "" {
+			return newError("no enclosing loop labeled %s to break", obj.Label)
+		}
+	case *object.Continue:
+		if obj.Label != "" {
+			return newError("no enclosing loop labeled %s to continue", obj.Label)
+		}
+	}
+	return newError("%s outside of a loop", obj.Inspect())
+}
+
+func evalPrefixExpressions(op string, val object.Object, tok token.Token) object.Object {
 	switch op {
 	case "!":
 		return evalBangOperatorExpression(val)
 	case "-":
-		return evalMinusPrefixOperator(val)
+		return evalMinusPrefixOperator(val, tok)
 	default:
-		return newError("unknown operator: %s%s", op, val.Type())
+		return newErrorAt(tok, "unknown operator: %s%s", op, val.Type())
 	}
 }
 
 func evalBangOperatorExpression(val object.Object) object.Object {
-	if val == TRUE {
-		return FALSE
-	} else if val == FALSE {
-		return TRUE
-	} else if val == NULL {
-		return TRUE
+	return nativeBoolObject(!isTruthy(val))
+}
+
+// only false and null are falsy, every other value including 0, "" and [] is truthy
+func isTruthy(obj object.Object) bool {
+	switch obj {
+	case FALSE, NULL:
+		return false
+	default:
+		return true
 	}
-	return FALSE
 }
 
-func evalMinusPrefixOperator(val object.Object) object.Object {
+func evalMinusPrefixOperator(val object.Object, tok token.Token) object.Object {
+	if val.Type() == object.FLOAT_OBJ {
+		return &object.Float{Value: -val.(*object.Float).Value}
+	}
 	if val.Type() != object.INTEGER_OBJ {
-		return newError("unknown operator: -%s", val.Type())
+		return newErrorAt(tok, "unknown operator: -%s", val.Type())
 	}
 
 	value := val.(*object.Integer).Value
-	return &object.Integer{Value: -value}
+	return newInteger(-value)
+}
+
+// hash keys that let a hash on the left of an operator supply its own implementation
+var operatorMethods = map[string]string{
+	"+":  "__add__",
+	"-":  "__sub__",
+	"==": "__eq__",
+	"!=": "__eq__",
 }
 
-func evalInfixExpression(op string, right object.Object, left object.Object) object.Object {
+func evalInfixExpression(op string, right object.Object, left object.Object, tok token.Token) object.Object {
+	if res, ok := evalOperatorMethod(op, right, left); ok {
+		return res
+	}
 	switch {
 	case right.Type() == object.INTEGER_OBJ && left.Type() == object.INTEGER_OBJ:
-		return evalInfixIntegerExpression(op, right, left)
+		return evalInfixIntegerExpression(op, right, left, tok)
+	case right.Type() == object.FLOAT_OBJ && left.Type() == object.FLOAT_OBJ:
+		return evalInfixFloatExpression(op, right, left, tok)
+	case isNumber(right) && isNumber(left):
+		return evalInfixFloatExpression(op, toFloat(right), toFloat(left), tok)
 	case right.Type() == object.STRING_OBJ && left.Type() == object.STRING_OBJ:
-		return evalInfixStringExpression(op, right, left)
+		return evalInfixStringExpression(op, right, left, tok)
+	case op == "+" && config.CoerceStringConcat && isStringAndNumber(left, right):
+		return &object.String{Value: left.Inspect() + right.Inspect()}
 	case op == "==":
 		return nativeBoolObject(left == right)
 	case op == "!=":
 		return nativeBoolObject(right != left)
 	case right.Type() != left.Type():
-		return newError("type mismatch: %s %s %s", left.Type(), op, right.Type())
+		return newErrorAt(tok, "type mismatch: %s %s %s", left.Type(), op, right.Type())
 	default:
-		return newError("unknown operator: %s %s %s", left.Type(), op, right.Type())
+		return newErrorAt(tok, "unknown operator: %s %s %s", left.Type(), op, right.Type())
 	}
 
 }
 
-func evalHashExpression(exp *ast.HashExpression, env *object.Enviroment) object.Object {
-	pairs := make(map[object.Object]object.Object)
-	for key, val := range exp.Pairs {
-		if key.TokenLiteral() == "IDENTIFIER" {
-			keyy, booll := env.Get(key.(*ast.Identifier).Value)
-			if !booll {
-				return newError("key isn't valid")
-			}
-			vall := Eval(val, env)
-			pairs[keyy] = vall
+// calls left's method for op with (left, right) when left is a hash that defines one,
+// != is answered by negating __eq__
+func evalOperatorMethod(op string, right object.Object, left object.Object) (object.Object, bool) {
+	hash, ok := left.(*object.Hash)
+	if !ok {
+		return nil, false
+	}
+	name, ok := operatorMethods[op]
+	if !ok {
+		return nil, false
+	}
+	pair, ok := hash.Pairs[(&object.String{Value: name}).HashKey()]
+	if !ok {
+		return nil, false
+	}
+	res := applyFunction(pair.Value, []object.Object{left, right})
+	if op == "!=" && !isError(res) {
+		return nativeBoolObject(!isTruthy(res)), true
+	}
+	return res, true
+}
 
-		} else {
-			keyy := Eval(key, env)
-			vall := Eval(val, env)
-			pairs[keyy] = vall
+// evaluates && and || without touching the right operand once the left decides the result
+func evalLogicalExpression(node *ast.InfixExpression, env *object.Enviroment) object.Object {
+	left := Eval(node.Left, env)
+	if isError(left) {
+		return left
+	}
+	if node.Operator == "&&" && !isTruthy(left) {
+		return FALSE
+	}
+	if node.Operator == "||" && isTruthy(left) {
+		return TRUE
+	}
+	right := Eval(node.Right, env)
+	if isError(right) {
+		return right
+	}
+	return nativeBoolObject(isTruthy(right))
+}
 
+func evalHashExpression(exp *ast.HashExpression, env *object.Enviroment) object.Object {
+	pairs := make(map[object.HashKey]object.HashPair)
+	for _, spreadNode := range exp.Spreads {
+		spread := Eval(spreadNode, env)
+		if isError(spread) {
+			return spread
+		}
+		hash, ok := spread.(*object.Hash)
+		if !ok {
+			return newErrorAt(exp.Token, "cannot spread %s into a hash", spread.Type())
+		}
+		for key, pair := range hash.Pairs {
+			pairs[key] = pair
 		}
-
+	}
+	for keyNode, valueNode := range exp.Pairs {
+		key := Eval(keyNode, env)
+		if isError(key) {
+			return key
+		}
+		hashable, ok := key.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", key.Type())
+		}
+		value := Eval(valueNode, env)
+		if isError(value) {
+			return value
+		}
+		pairs[hashable.HashKey()] = object.HashPair{Key: key, Value: value}
 	}
 	return &object.Hash{Pairs: pairs}
 }
 
-func evalInfixStringExpression(op string, right object.Object, left object.Object) object.Object {
-	if op != "+" {
-		return newError("unknown operator: %s %s %s",
-			left.Type(), op, right.Type())
-	}
-
+func evalInfixStringExpression(op string, right object.Object, left object.Object, tok token.Token) object.Object {
 	leftVal := left.(*object.String).Value
 	rightVal := right.(*object.String).Value
 
-	return &object.String{Value: leftVal + rightVal}
+	switch op {
+	case "+":
+		return &object.String{Value: leftVal + rightVal}
+	case "==":
+		return nativeBoolObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolObject(leftVal != rightVal)
+	}
+
+	return newErrorAt(tok, "unknown operator: %s %s %s",
+		left.Type(), op, right.Type())
 }
 
-func evalInfixIntegerExpression(op string, right object.Object, left object.Object) object.Object {
+func evalInfixIntegerExpression(op string, right object.Object, left object.Object, tok token.Token) object.Object {
 	right_val := right.(*object.Integer).Value
 	left_val := left.(*object.Integer).Value
 
 	switch op {
 	case "+":
-		return &object.Integer{Value: right_val + left_val}
+		return newInteger(right_val + left_val)
 	case "-":
-		return &object.Integer{Value: left_val - right_val}
+		return newInteger(left_val - right_val)
 	case "*":
-		return &object.Integer{Value: right_val * left_val}
+		return newInteger(right_val * left_val)
 	case "/":
-		return &object.Integer{Value: left_val / right_val}
+		if right_val == 0 {
+			return newErrorAt(tok, "division by zero")
+		}
+		return newInteger(left_val / right_val)
+	case "~/":
+		if right_val == 0 {
+			return newErrorAt(tok, "division by zero")
+		}
+		// / truncates toward zero, floor division rounds down instead
+		quotient := left_val / right_val
+		if left_val%right_val != 0 && (left_val < 0) != (right_val < 0) {
+			quotient--
+		}
+		return newInteger(quotient)
+	case "%":
+		if right_val == 0 {
+			return newErrorAt(tok, "division by zero")
+		}
+		return newInteger(left_val % right_val)
 	case ">":
 		return nativeBoolObject(left_val > right_val)
 	case "<":
 		return nativeBoolObject(left_val < right_val)
+	case ">=":
+		return nativeBoolObject(left_val >= right_val)
+	case "<=":
+		return nativeBoolObject(left_val <= right_val)
 	case "==":
 		return nativeBoolObject(left_val == right_val)
 	case "!=":
 		return nativeBoolObject(left_val != right_val)
 	}
 
-	return newError("unknown operator: %s %s %s", left.Type(), op, right.Type())
+	return newErrorAt(tok, "unknown operator: %s %s %s", left.Type(), op, right.Type())
+}
+
+func isNumber(obj object.Object) bool {
+	return obj.Type() == object.INTEGER_OBJ || obj.Type() == object.FLOAT_OBJ
+}
+
+func isStringAndNumber(left object.Object, right object.Object) bool {
+	return left.Type() == object.STRING_OBJ && isNumber(right) ||
+		isNumber(left) && right.Type() == object.STRING_OBJ
+}
+
+// widens an integer to a float so mixed operands can share float arithmetic
+func toFloat(obj object.Object) object.Object {
+	if i, ok := obj.(*object.Integer); ok {
+		return &object.Float{Value: float64(i.Value)}
+	}
+	return obj
+}
+
+func evalInfixFloatExpression(op string, right object.Object, left object.Object, tok token.Token) object.Object {
+	right_val := right.(*object.Float).Value
+	left_val := left.(*object.Float).Value
+
+	switch op {
+	case "+":
+		return &object.Float{Value: left_val + right_val}
+	case "-":
+		return &object.Float{Value: left_val - right_val}
+	case "*":
+		return &object.Float{Value: left_val * right_val}
+	case "/":
+		// follows IEEE 754, so dividing by zero gives +Inf, -Inf or NaN rather than an error
+		return &object.Float{Value: left_val / right_val}
+	case "~/":
+		if right_val == 0 {
+			return newErrorAt(tok, "division by zero")
+		}
+		return &object.Float{Value: math.Floor(left_val / right_val)}
+	case ">":
+		return nativeBoolObject(left_val > right_val)
+	case "<":
+		return nativeBoolObject(left_val < right_val)
+	case ">=":
+		return nativeBoolObject(left_val >= right_val)
+	case "<=":
+		return nativeBoolObject(left_val <= right_val)
+	case "==":
+		return nativeBoolObject(left_val == right_val)
+	case "!=":
+		return nativeBoolObject(left_val != right_val)
+	}
+
+	return newErrorAt(tok, "unknown operator: %s %s %s", left.Type(), op, right.Type())
 }
 
 func evalIfExpression(ie *ast.IfExpression, env *object.Enviroment) object.Object {
@@ -292,7 +627,7 @@ func evalIfExpression(ie *ast.IfExpression, env *object.Enviroment) object.Objec
 	if isError(res) {
 		return res
 	}
-	if res.Type() == object.BOOLEAN_OBJ && (res == NULL || res == FALSE) {
+	if !isTruthy(res) {
 		if ie.Alternatives == nil {
 			return NULL
 		}
@@ -303,13 +638,133 @@ func evalIfExpression(ie *ast.IfExpression, env *object.Enviroment) object.Objec
 
 }
 
+func evalDoExpression(de *ast.DoExpression, env *object.Enviroment) object.Object {
+	res := evalStatements(de.Body.Statements, object.NewEnclosedEnviroment(env))
+	if res == nil {
+		return NULL
+	}
+	return res
+}
+
+// folds one pass of a loop body into the loop's result, reporting whether
+// the loop has to stop and hand back what it returns. a break or continue
+// naming another label stops this loop and is handed on to the one outside
+func nextLoopResult(res, result object.Object, label *ast.Identifier) (object.Object, bool) {
+	if res == nil {
+		return result, false
+	}
+	switch res := res.(type) {
+	case *object.Break:
+		if !matchesLabel(res.Label, label) {
+			return res, true
+		}
+		return result, true
+	case *object.Continue:
+		if !matchesLabel(res.Label, label) {
+			return res, true
+		}
+		return result, false
+	case *object.ReturnValue, *object.Error:
+		return res, true
+	}
+	return res, false
+}
+
+// an unlabeled break or continue belongs to the nearest loop
+func matchesLabel(target string, label *ast.Identifier) bool {
+	return target == "" || label != nil && label.Value == target
+}
+
+// runs the body until the condition is no longer truthy, returning the
+// last value the body produced
+func evalWhileStatement(ws *ast.WhileStatement, env *object.Enviroment) object.Object {
+	var result object.Object = NULL
+	for {
+		cond := Eval(ws.Condition, env)
+		if isError(cond) {
+			return cond
+		}
+		if !isTruthy(cond) {
+			return result
+		}
+		var done bool
+		if result, done = nextLoopResult(Eval(ws.Body, env), result, ws.Label); done {
+			return result
+		}
+	}
+}
+
+// runs init once in a scope of its own, then the body and post for as long
+// as the condition holds, a missing condition loops until a return or error
+func evalForStatement(fs *ast.ForStatement, env *object.Enviroment) object.Object {
+	loopEnv := object.NewEnclosedEnviroment(env)
+	if fs.Init != nil {
+		if res := Eval(fs.Init, loopEnv); isError(res) {
+			return res
+		}
+	}
+	var result object.Object = NULL
+	for {
+		if fs.Condition != nil {
+			cond := Eval(fs.Condition, loopEnv)
+			if isError(cond) {
+				return cond
+			}
+			if !isTruthy(cond) {
+				return result
+			}
+		}
+		var done bool
+		if result, done = nextLoopResult(Eval(fs.Body, loopEnv), result, fs.Label); done {
+			return result
+		}
+		if fs.Post != nil {
+			if res := Eval(fs.Post, loopEnv); isError(res) {
+				return res
+			}
+		}
+	}
+}
+
+// binds every element of an array, or every key of a hash in sorted order,
+// to the loop variable in a fresh scope and runs the body with it
+func evalForInStatement(fs *ast.ForInStatement, env *object.Enviroment) object.Object {
+	collection := Eval(fs.Collection, env)
+	if isError(collection) {
+		return collection
+	}
+	var items []object.Object
+	switch collection := collection.(type) {
+	case *object.Array:
+		items = collection.Elements
+	case *object.Hash:
+		for _, pair := range sortedPairs(collection) {
+			items = append(items, pair.Key)
+		}
+	default:
+		return newErrorAt(fs.Token, "cannot iterate over %s", collection.Type())
+	}
+
+	var result object.Object = NULL
+	for _, item := range items {
+		iterEnv := object.NewEnclosedEnviroment(env)
+		iterEnv.Set(fs.Variable.Value, item)
+		var done bool
+		if result, done = nextLoopResult(Eval(fs.Body, iterEnv), result, fs.Label); done {
+			return result
+		}
+	}
+	return result
+}
+
 func evalStatements(stmts []ast.Statement, env *object.Enviroment) object.Object {
 	var result object.Object
 
 	for _, statement := range stmts {
 		result = Eval(statement, env)
 		if result != nil {
-			if result.Type() == object.RETURN_VALUE_OBJ || result.Type() == object.ERROR_OBJ {
+			switch result.Type() {
+			case object.RETURN_VALUE_OBJ, object.ERROR_OBJ, object.BREAK_OBJ, object.CONTINUE_OBJ:
 				return result
 			}
 		}
@@ -318,13 +773,32 @@ func evalStatements(stmts []ast.Statement, env *object.Enviroment) object.Object
 	return result
 }
 
+func evalAssignStatement(node *ast.AssignStatement, env *object.Enviroment) object.Object {
+	current, ok := env.Get(node.Name.Value)
+	if !ok {
+		return newErrorAt(node.Token, "cannot assign to undefined variable %s", node.Name.Value)
+	}
+	val := Eval(node.Value, env)
+	if isError(val) {
+		return val
+	}
+	if op := strings.TrimSuffix(node.Operator, "="); op != "" {
+		val = evalInfixExpression(op, val, current, node.Token)
+		if isError(val) {
+			return val
+		}
+	}
+	env.Assign(node.Name.Value, val)
+	return val
+}
+
 func evalIdentifier(node *ast.Identifier, env *object.Enviroment) object.Object {
 	if val, ok := env.Get(node.Value); ok {
 		return val
-	} else if val, ok := builtins[node.Value]; ok {
+	} else if val, ok := lookupBuiltin(node.Value); ok {
 		return val
 	}
-	return newError("identifier not found: %s", node.Value)
+	return newErrorAt(node.Token, "identifier not found: %s", node.Value)
 }
 
 func evalExpressions(exps []ast.Expression, env *object.Enviroment) []object.Object {
@@ -342,28 +816,77 @@ func evalExpressions(exps []ast.Expression, env *object.Enviroment) []object.Obj
 func applyFunction(fn object.Object, params []object.Object) object.Object {
 	switch fn := fn.(type) {
 	case *object.Function:
+		if len(params) < len(fn.Parameters) {
+			return newError("wrong number of arguments. got=%d, want=%d", len(params), len(fn.Parameters))
+		}
 		new_env := object.NewEnclosedEnviroment(fn.Env)
 		for paramID, p := range fn.Parameters {
 			new_env.Set(p.Value, params[paramID])
 		}
 		evaluated := Eval(fn.Body, new_env)
-		if evaluated, ok := evaluated.(*object.ReturnValue); ok {
-			return evaluated.Value
+		switch result := evaluated.(type) {
+		case *object.ReturnValue:
+			return result.Value
+		case *object.Break, *object.Continue:
+			// a loop around the call cannot be broken from inside the function
+			return loopControlError(result)
+		}
+		// an empty body or one ending in a let has no value
+		if evaluated == nil {
+			return NULL
 		}
 		return evaluated
 
 	case *object.Builtin:
 		return fn.Fn(params...)
 
+	case *object.Memoized:
+		key, err := memoKey(params)
+		if err != nil {
+			return err
+		}
+		if cached, ok := fn.Cache[key]; ok {
+			return cached
+		}
+		res := applyFunction(fn.Fn, params)
+		if !isError(res) {
+			fn.Cache[key] = res
+		}
+		return res
+
 	default:
 		return newError("not a function: %s", fn.Type())
 	}
 }
 
+// joins the hash keys of params into one cache key for a memoized call
+func memoKey(params []object.Object) (string, object.Object) {
+	var key strings.Builder
+	for _, p := range params {
+		hashable, ok := p.(object.Hashable)
+		if !ok {
+			return "", newError("unusable as memoize key: %s", p.Type())
+		}
+		hk := hashable.HashKey()
+		fmt.Fprintf(&key, "%s:%d,", hk.Type, hk.Value)
+	}
+	return key.String(), nil
+}
+
 func newError(format string, a ...interface{}) object.Object {
 	return &object.Error{Message: fmt.Sprintf(format, a...)}
 }
 
+// like newError but prefixed with the line tok was found on
+func newErrorAt(tok token.Token, format string, a ...interface{}) object.Object {
+	return newError("line %d: %s", tok.Line, fmt.Sprintf(format, a...))
+}
+
+// reports something suspicious on the error writer without stopping the program
+func warnAt(tok token.Token, format string, a ...interface{}) {
+	fmt.Fprintf(ioContext.Err, "warning: line %d: %s\n", tok.Line, fmt.Sprintf(format, a...))
+}
+
 func isError(obj object.Object) bool {
 	if obj != nil {
 		return obj.Type() == object.ERROR_OBJ
@@ -1,10 +1,16 @@
 package evaluator
 
 import (
+	"bytes"
 	"interpreter/lexer"
 	"interpreter/object"
 	"interpreter/parser"
+	"math"
+	"os"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestEvalIntegerExpression(t *testing.T) {
@@ -83,6 +89,11 @@ func TestBangOperator(t *testing.T) {
 		{"!!true", true},
 		{"!!false", false},
 		{"!!5", true},
+		{"!0", false},
+		{`!""`, false},
+		{"![]", false},
+		{"!null", true},
+		{"!!null", false},
 		{"true", true},
 		{"false", false},
 		{"1 < 2", true},
@@ -93,6 +104,13 @@ func TestBangOperator(t *testing.T) {
 		{"1 != 1", false},
 		{"1 == 2", false},
 		{"1 != 2", true},
+		{"5 <= 5", true},
+		{"4 <= 5", true},
+		{"6 <= 5", false},
+		{"6 >= 7", false},
+		{"7 >= 7", true},
+		{"1.5 <= 1.5", true},
+		{"2.5 >= 3", false},
 		{"true == true", true},
 		{"false == false", true},
 		{"true == false", false},
@@ -121,6 +139,12 @@ func TestIfElseExpressions(t *testing.T) {
 		{"if (1 > 2) { 10 }", nil},
 		{"if (1 > 2) { 10 } else { 20 }", 20},
 		{"if (1 < 2) { 10 } else { 20 }", 10},
+		{"if (null) { 10 } else { 20 }", 20},
+		{"if (null) { 10 }", nil},
+		{"if (0) { 10 } else { 20 }", 10},
+		{`if ("") { 10 } else { 20 }`, 10},
+		{"if ([]) { 10 } else { 20 }", 10},
+		{"if (first([])) { 10 } else { 20 }", 20},
 	}
 	for _, tt := range tests {
 		evaluated := testEval(tt.input)
@@ -168,27 +192,27 @@ func TestErrorHandling(t *testing.T) {
 	}{
 		{
 			"5 + true;",
-			"type mismatch: INTEGER + BOOLEAN",
+			"line 1: type mismatch: INTEGER + BOOLEAN",
 		},
 		{
 			"5 + true; 5;",
-			"type mismatch: INTEGER + BOOLEAN",
+			"line 1: type mismatch: INTEGER + BOOLEAN",
 		},
 		{
 			"-true",
-			"unknown operator: -BOOLEAN",
+			"line 1: unknown operator: -BOOLEAN",
 		},
 		{
 			"true + false;",
-			"unknown operator: BOOLEAN + BOOLEAN",
+			"line 1: unknown operator: BOOLEAN + BOOLEAN",
 		},
 		{
 			"5; true + false; 5",
-			"unknown operator: BOOLEAN + BOOLEAN",
+			"line 1: unknown operator: BOOLEAN + BOOLEAN",
 		},
 		{
 			"if (10 > 1) { true + false; }",
-			"unknown operator: BOOLEAN + BOOLEAN",
+			"line 1: unknown operator: BOOLEAN + BOOLEAN",
 		},
 		{
 			`
@@ -200,15 +224,19 @@ func TestErrorHandling(t *testing.T) {
 	return 1;
 	}
 	`,
-			"unknown operator: BOOLEAN + BOOLEAN",
+			"line 5: unknown operator: BOOLEAN + BOOLEAN",
 		},
 		{
 			"foobar",
-			"identifier not found: foobar",
+			"line 1: identifier not found: foobar",
+		},
+		{
+			"let a = 1;\nlet b = 2;\n\n\nfoo",
+			"line 5: identifier not found: foo",
 		},
 		{
 			`"Hello" - "World"`,
-			"unknown operator: STRING - STRING",
+			"line 1: unknown operator: STRING - STRING",
 		},
 	}
 	for _, tt := range tests {
@@ -241,6 +269,277 @@ func TestLetStatements(t *testing.T) {
 	}
 }
 
+func TestCompoundAssignment(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let x = 1; x += 4; x", 5},
+		{"let x = 10; x -= 3; x", 7},
+		{"let x = 3; x *= 4; x", 12},
+		{"let x = 12; x /= 4; x", 3},
+		{"let x = 1; let f = fn() { x += 1 }; f(); f(); x", 3},
+		{`let s = "a"; s += "b"; s`, "ab"},
+		{"y += 1", "line 1: cannot assign to undefined variable y"},
+		{"let x = 1; x /= 0", "line 1: division by zero"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			if str, ok := evaluated.(*object.String); ok {
+				if str.Value != expected {
+					t.Errorf("String has wrong value. got=%q, want=%q", str.Value, expected)
+				}
+				continue
+			}
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestInspectSelfReferentialArray(t *testing.T) {
+	arr := &object.Array{Elements: []object.Object{&object.Integer{Value: 1}}}
+	arr.Elements = append(arr.Elements, arr)
+	if got := arr.Inspect(); got != "[1, ...]" {
+		t.Errorf("wrong inspect output. got=%q", got)
+	}
+
+	hash := &object.Hash{Pairs: map[object.HashKey]object.HashPair{}}
+	key := &object.String{Value: "self"}
+	hash.Pairs[key.HashKey()] = object.HashPair{Key: key, Value: hash}
+	if got := hash.Inspect(); got != "{self:...}" {
+		t.Errorf("wrong inspect output. got=%q", got)
+	}
+
+	// the same array twice is not a cycle and is printed in full
+	inner := testEval("[1, 2]")
+	shared := &object.Array{Elements: []object.Object{inner, inner}}
+	if got := shared.Inspect(); got != "[[1, 2], [1, 2]]" {
+		t.Errorf("wrong inspect output. got=%q", got)
+	}
+}
+
+func TestInspectDepthLimit(t *testing.T) {
+	old := object.MaxInspectDepth
+	object.MaxInspectDepth = 2
+	defer func() { object.MaxInspectDepth = old }()
+
+	if got := testEval("[[[1]]]").Inspect(); got != "[[...]]" {
+		t.Errorf("wrong inspect output. got=%q", got)
+	}
+}
+
+func TestReassignment(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let x = 1; x = 2; x", 2},
+		{"let x = 1; x = x + 10; x", 11},
+		// assigning inside a block updates the outer binding
+		{"let x = 1; if (true) { x = 5; }; x", 5},
+		{"let x = 1; let f = fn() { x = 7; }; f(); x", 7},
+		{"let x = 1; do { x = 3; }; x", 3},
+		// a let in an inner scope shadows, assignment then only touches the shadow
+		{"let x = 1; let f = fn() { let x = 2; x = 3; x }; f() * 10 + x", 31},
+		{"y = 1", "line 1: cannot assign to undefined variable y"},
+		{"let x = 1; x = -true", "line 1: unknown operator: -BOOLEAN"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestWhileStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let i = 0; let sum = 0; while (i < 5) { i += 1; sum += i; }; sum", 15},
+		{"let i = 0; while (i < 3) { i += 1; i * 10 }", 30},
+		{"while (false) { 1 }", nil},
+		{"let f = fn() { let i = 0; while (true) { i += 1; if (i == 3) { return i; } } }; f()", 3},
+		{"while (true) { 1 + true }", "line 1: type mismatch: INTEGER + BOOLEAN"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestForStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let n = 0; for (let i = 0; i < 3; i += 1) { n *= 10; n += i + 1; }; n", 123},
+		{"let n = 0; for (; n < 4;) { n += 1 }; n", 4},
+		{"for (let i = 0; i < 3; i += 1) { i * 2 }", 4},
+		{"for (let i = 0; false; i += 1) { i }", nil},
+		{"let f = fn() { for (let i = 0;; i += 1) { if (i == 5) { return i; } } }; f()", 5},
+		{"for (let i = 0; i < 3; i += 1) { i }; i", "line 1: identifier not found: i"},
+		{"for (let i = 0; i < 3; i += true) { i }", "line 1: type mismatch: INTEGER + BOOLEAN"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestForInStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let sum = 0; for (x in [1, 2, 3]) { sum += x; }; sum", 6},
+		{"for (x in []) { x }", nil},
+		// hashes bind each key, visited in sorted order
+		{`let h = {"b": 2, "a": 1}; let n = 0; for (k in h) { n *= 10; n += h[k]; }; n`, 12},
+		{`let f = fn() { for (x in [1, 2, 3]) { if (x == 2) { return x * 10; } } }; f()`, 20},
+		{"for (x in [1]) { x }; x", "line 1: identifier not found: x"},
+		{"for (x in 5) { x }", "line 1: cannot iterate over INTEGER"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestBreakAndContinue(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let i = 0; while (true) { i += 1; if (i == 4) { break; } }; i", 4},
+		{"let i = 0; let sum = 0; while (i < 6) { i += 1; if (i % 2 == 0) { continue; } sum += i; }; sum", 9},
+		{"let sum = 0; for (let i = 0; i < 10; i += 1) { if (i == 3) { continue; } if (i == 5) { break; } sum += i; }; sum", 7},
+		{"let sum = 0; for (x in [1, 2, 3, 4]) { if (x == 3) { break; } sum += x; }; sum", 3},
+		// break only leaves the innermost loop
+		{"let n = 0; for (x in [1, 2]) { for (y in [1, 2, 3]) { if (y == 2) { break; } n += 1; } }; n", 2},
+		// the loop's value comes from the last pass that ran to the end
+		{"let i = 0; while (true) { i += 1; if (i == 3) { break; } i * 10 }", 20},
+		{"break;", "break outside of a loop"},
+		{"if (true) { continue; }", "continue outside of a loop"},
+		{"let f = fn() { break; }; while (true) { f(); }", "break outside of a loop"},
+		// a label lets the inner loop leave or continue the outer one
+		{"let n = 0; outer: for (x in [1, 2, 3]) { for (y in [1, 2, 3]) { if (y == 2) { break outer; } n += 1; } }; n", 1},
+		{"let n = 0; outer: for (x in [1, 2, 3]) { for (y in [1, 2, 3]) { if (y == 2) { continue outer; } n += 1; } n += 100; }; n", 3},
+		{"let i = 0; outer: while (true) { inner: while (true) { i += 1; if (i == 5) { break outer; } if (i % 2 == 0) { break inner; } } }; i", 5},
+		{"let n = 0; outer: for (let i = 0; i < 3; i += 1) { while (true) { n += 1; break outer; } }; n", 1},
+		// a label naming the innermost loop acts like a plain break
+		{"let n = 0; for (x in [1, 2]) { inner: for (y in [1, 2, 3]) { if (y == 2) { break inner; } n += 1; } }; n", 2},
+		{"outer: while (true) { break inner; }", "no enclosing loop labeled inner to break"},
+		// without a semicolon the next line is still a statement, not a label
+		{"let foo = 1; while (true) { break\nfoo }; foo", 1},
+		{"let n = 0; while (true) { n += 1; break\nfoo: while (true) { n += 10 } }; n", 1},
+		{"outer: while (true) { let f = fn() { continue outer; }; f(); }", "no enclosing loop labeled outer to continue"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestShadowingBuiltinWarns(t *testing.T) {
+	defer SetIO(DefaultIO())
+
+	var errOut bytes.Buffer
+	SetIO(IOContext{In: strings.NewReader(""), Out: &errOut, Err: &errOut})
+
+	testIntegerObject(t, testEval("let max = 3; max"), 3)
+	testIntegerObject(t, testEval("let len = fn(x) { 1 }; len(\"abc\")"), 1)
+	expected := "warning: line 1: let max shadows the builtin `max`\n" +
+		"warning: line 1: let len shadows the builtin `len`\n"
+	if errOut.String() != expected {
+		t.Errorf("wrong warnings. expected=%q, got=%q", expected, errOut.String())
+	}
+
+	errOut.Reset()
+	testIntegerObject(t, testEval("let values = [1]; len(values)"), 1)
+	testIntegerObject(t, testEval("let f = fn(max) { max }; f(2)"), 2)
+	testIntegerObject(t, testEval("let total = 1; total"), 1)
+	if errOut.String() != "warning: line 1: let values shadows the builtin `values`\n" {
+		t.Errorf("wrong warnings. got=%q", errOut.String())
+	}
+}
+
 func TestFunctionObject(t *testing.T) {
 	input := "fn(x) { x + 2; };"
 	evaluated := testEval(input)
@@ -302,6 +601,27 @@ func TestStringConcatenation(t *testing.T) {
 	}
 }
 
+func TestPushBuiltin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`push([1, 2], 3)`, "[1, 2, 3]"},
+		{`push([], "a")`, "[a]"},
+		{`let a = [1]; let b = push(a, 2); a`, "[1]"},
+		{`let a = push([1], 2); let b = push(a, 3); let c = push(a, 4); b`, "[1, 2, 3]"},
+		{`let f = push; f([1], 2)`, "[1, 2]"},
+		{`push([1])`, "ERROR: wrong number of arguments. got=1, want=2"},
+		{`push(1, 2)`, "ERROR: argument to `push` must be ARRAY, got INTEGER"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s - wrong result. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
 func TestBuiltinFunctions(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -405,3 +725,2142 @@ func TestArrayIndexExpressions(t *testing.T) {
 		}
 	}
 }
+
+func TestEnvBuiltins(t *testing.T) {
+	os.Setenv("MONKEY_TEST_VAR", "banana")
+	defer os.Unsetenv("MONKEY_TEST_VAR")
+
+	evaluated := testEval(`getenv("MONKEY_TEST_VAR")`)
+	str, ok := evaluated.(*object.String)
+	if !ok {
+		t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
+	}
+	if str.Value != "banana" {
+		t.Errorf("String has wrong value. got=%q", str.Value)
+	}
+
+	testNullObject(t, testEval(`getenv("MONKEY_TEST_UNSET_VAR")`))
+
+	testNullObject(t, testEval(`setenv("MONKEY_TEST_VAR", "apple")`))
+	if got := os.Getenv("MONKEY_TEST_VAR"); got != "apple" {
+		t.Errorf("setenv did not update environment. got=%q", got)
+	}
+
+	// hosts keep scripts out of the environment by hiding both builtins
+	defer SetConfig(DefaultConfig())
+	SetConfig(Config{IntegerBits: 64, DisabledBuiltins: []string{"getenv", "setenv"}})
+	errObj, ok := testEval(`getenv("MONKEY_TEST_VAR")`).(*object.Error)
+	if !ok {
+		t.Fatalf("expected error when env access disabled")
+	}
+	if errObj.Message != "line 1: identifier not found: getenv" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
+
+func TestInputBuiltin(t *testing.T) {
+	SetInput(strings.NewReader("first line\nsecond line\n"))
+	defer SetInput(os.Stdin)
+
+	expected := []string{"first line", "second line"}
+	for _, want := range expected {
+		evaluated := testEval(`input()`)
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
+		}
+		if str.Value != want {
+			t.Errorf("String has wrong value. got=%q, want=%q", str.Value, want)
+		}
+	}
+	testNullObject(t, testEval(`input()`))
+}
+
+func TestInputPrompt(t *testing.T) {
+	defer SetIO(DefaultIO())
+
+	var out bytes.Buffer
+	SetIO(IOContext{In: strings.NewReader("Ada"), Out: &out})
+
+	// the last line is returned even without a newline after it
+	evaluated := testEval(`input("name: ")`)
+	if evaluated.Inspect() != "Ada" {
+		t.Errorf("wrong input. expected=%q, got=%q", "Ada", evaluated.Inspect())
+	}
+	if out.String() != "name: " {
+		t.Errorf("prompt should be written without a newline. got=%q", out.String())
+	}
+	testNullObject(t, testEval(`input("again: ")`))
+	errObj, ok := testEval(`input(1)`).(*object.Error)
+	if !ok || errObj.Message != "argument to `input` must be STRING, got INTEGER" {
+		t.Errorf("expected an argument error, got=%v", errObj)
+	}
+}
+
+func TestLinesBuiltin(t *testing.T) {
+	SetInput(strings.NewReader("alpha\nbeta\r\ngamma"))
+	defer SetInput(os.Stdin)
+
+	evaluated := testEval(`lines()`)
+	arr, ok := evaluated.(*object.Array)
+	if !ok {
+		t.Fatalf("object is not Array. got=%T (%+v)", evaluated, evaluated)
+	}
+	expected := []string{"alpha", "beta", "gamma"}
+	if len(arr.Elements) != len(expected) {
+		t.Fatalf("array has wrong num of elements. got=%d", len(arr.Elements))
+	}
+	for i, want := range expected {
+		str, ok := arr.Elements[i].(*object.String)
+		if !ok || str.Value != want {
+			t.Errorf("lines()[%d] wrong. got=%+v, want=%q", i, arr.Elements[i], want)
+		}
+	}
+}
+
+func TestStringQuotedRoundTrip(t *testing.T) {
+	inputs := []string{
+		"",
+		"plain",
+		"with \"quotes\"",
+		`back\slash`,
+		"tab\tnew\nline\rreturn",
+		"bell\x07 and nul\x00",
+		"café   separator",
+		"bad utf8 \xff\xfe",
+	}
+	for _, s := range inputs {
+		quoted := (&object.String{Value: s}).Quoted()
+		evaluated := testEval(quoted)
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Errorf("object is not String for %q. got=%T (%+v)", quoted, evaluated, evaluated)
+			continue
+		}
+		if str.Value != s {
+			t.Errorf("round trip failed for %q. got=%q, want=%q", quoted, str.Value, s)
+		}
+	}
+}
+
+func TestIntegerWidth(t *testing.T) {
+	defer SetConfig(DefaultConfig())
+
+	testIntegerObject(t, testEval("2147483647 + 1"), 2147483648)
+
+	SetConfig(Config{IntegerBits: 32})
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"2147483647 + 1", "integer overflow: 2147483648 does not fit in 32 bits"},
+		{"-2147483647 - 2", "integer overflow: -2147483649 does not fit in 32 bits"},
+		{"3000000000", "integer overflow: 3000000000 does not fit in 32 bits"},
+		{"65536 * 65536", "integer overflow: 4294967296 does not fit in 32 bits"},
+	}
+	for _, tt := range tests {
+		errObj, ok := testEval(tt.input).(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q", tt.input)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q",
+				tt.expectedMessage, errObj.Message)
+		}
+	}
+	testIntegerObject(t, testEval("2147483646 + 1"), 2147483647)
+	testIntegerObject(t, testEval("-2147483648"), -2147483648)
+
+	SetConfig(Config{IntegerBits: 32, WrapOverflow: true})
+	testIntegerObject(t, testEval("2147483647 + 1"), -2147483648)
+	testIntegerObject(t, testEval("3000000000"), -1294967296)
+	testIntegerObject(t, testEval("65536 * 65536"), 0)
+}
+
+func TestDoExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"do { 5 }", 5},
+		{"let x = do { let a = 1; a + 2 }; x;", 3},
+		{"do { 1; 2; 3 } * 2", 6},
+		{"let a = 10; do { let a = 1; a }; a;", 10},
+		{"let f = fn() { do { return 4; 5 }; 6 }; f();", 4},
+		{"do { }", nil},
+		{"do { let a = 1; }", nil},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestGeneratorBuiltin(t *testing.T) {
+	input := `
+	let gen = generator(fn(yield) {
+		yield(1);
+		yield(2 * 2);
+		yield(3 + 6);
+	});
+	gen;`
+	evaluated := testEval(input)
+	result, ok := evaluated.(*object.Array)
+	if !ok {
+		t.Fatalf("object is not Array. got=%T (%+v)", evaluated, evaluated)
+	}
+	if len(result.Elements) != 3 {
+		t.Fatalf("array has wrong num of elements. got=%d",
+			len(result.Elements))
+	}
+	testIntegerObject(t, result.Elements[0], 1)
+	testIntegerObject(t, result.Elements[1], 4)
+	testIntegerObject(t, result.Elements[2], 9)
+
+	errObj, ok := testEval(`generator(1)`).(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned for non-function argument")
+	}
+	if errObj.Message != "argument to `generator` must be FUNCTION, got INTEGER" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
+
+func TestRenderBuiltin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`render("Hi {name}", {"name": "Bob"})`, "Hi Bob"},
+		{`render("{a}+{b}={c}", {"a": 1, "b": 2, "c": 3})`, "1+2=3"},
+		{`render("Hi {missing}", {"name": "Bob"})`, "Hi {missing}"},
+		{`render("open {name", {"name": "Bob"})`, "open {name"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Errorf("object is not String. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+		if str.Value != tt.expected {
+			t.Errorf("String has wrong value. got=%q, want=%q", str.Value, tt.expected)
+		}
+	}
+}
+
+func TestBase64Builtins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`base64Encode("hello world")`, "aGVsbG8gd29ybGQ="},
+		{`base64Decode("aGVsbG8gd29ybGQ=")`, "hello world"},
+		{`base64Decode(base64Encode("round trip!"))`, "round trip!"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Errorf("object is not String. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+		if str.Value != tt.expected {
+			t.Errorf("String has wrong value. got=%q, want=%q", str.Value, tt.expected)
+		}
+	}
+
+	errObj, ok := testEval(`base64Decode("not base64!")`).(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned for malformed input")
+	}
+	if !strings.HasPrefix(errObj.Message, "could not decode base64") {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
+
+func testFloatObject(t *testing.T, obj object.Object, expected float64) bool {
+	result, ok := obj.(*object.Float)
+	if !ok {
+		t.Errorf("object is not Float. got=%T (%+v)", obj, obj)
+		return false
+	}
+	if result.Value != expected {
+		t.Errorf("object has wrong value. got=%g, want=%g", result.Value, expected)
+		return false
+	}
+	return true
+}
+
+func TestEvalFloatExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+		inspect  string
+	}{
+		{"2.5 + 1.5", 4.0, "4"},
+		{"3.0 / 2.0", 1.5, "1.5"},
+		{"5.5 - 0.25", 5.25, "5.25"},
+		{"1.5 * 4.0", 6.0, "6"},
+		{"-2.5", -2.5, "-2.5"},
+		{"1.5 < 2.5", true, "true"},
+		{"1.5 > 2.5", false, "false"},
+		{"1.5 == 1.5", true, "true"},
+		{"1.5 != 1.5", false, "false"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case float64:
+			testFloatObject(t, evaluated, expected)
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		}
+		if evaluated != nil && evaluated.Inspect() != tt.inspect {
+			t.Errorf("wrong Inspect for %q. got=%q, want=%q", tt.input, evaluated.Inspect(), tt.inspect)
+		}
+	}
+}
+
+func TestDigestBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`sha256("abc")`, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{`sha256("")`, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+		{`md5("abc")`, "900150983cd24fb0d6963f7d28e17f72"},
+		{`md5("")`, "d41d8cd98f00b204e9800998ecf8427e"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Errorf("object is not String. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+		if str.Value != tt.expected {
+			t.Errorf("String has wrong value. got=%q, want=%q", str.Value, tt.expected)
+		}
+	}
+}
+
+func TestMixedNumberArithmetic(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"2 + 3.5", 5.5},
+		{"3.5 + 2", 5.5},
+		{"5 - 0.5", 4.5},
+		{"0.5 - 5", -4.5},
+		{"2 * 1.5", 3.0},
+		{"1.5 * 2", 3.0},
+		{"4 / 2.0", 2.0},
+		{"4.0 / 2", 2.0},
+		{"4 / 2", 2},
+		{"1 < 1.5", true},
+		{"1.5 > 1", true},
+		{"2 == 2.0", true},
+		{"2.0 != 2", false},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case float64:
+			testFloatObject(t, evaluated, expected)
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestUUIDBuiltin(t *testing.T) {
+	SeedRandom(42)
+	first, ok := testEval(`uuid()`).(*object.String)
+	if !ok {
+		t.Fatalf("object is not String")
+	}
+	SeedRandom(42)
+	second := testEval(`uuid()`).(*object.String)
+	if first.Value != second.Value {
+		t.Errorf("uuid not deterministic for a fixed seed. got=%q and %q", first.Value, second.Value)
+	}
+	if first.Value != "538c7f96-b164-4f1b-97bb-9f4bb472e89f" {
+		t.Errorf("uuid has wrong value for seed 42. got=%q", first.Value)
+	}
+
+	format := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	if !format.MatchString(first.Value) {
+		t.Errorf("uuid has invalid format. got=%q", first.Value)
+	}
+}
+
+func TestDeepMergeBuiltin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`let m = deepMerge({"x": {"a": 1}}, {"x": {"b": 2}}); m["x"]["a"]`, 1},
+		{`let m = deepMerge({"x": {"a": 1}}, {"x": {"b": 2}}); m["x"]["b"]`, 2},
+		{`let m = deepMerge({"x": {"a": 1}, "y": 1}, {"y": 2}); m["y"]`, 2},
+		{`let m = deepMerge({"x": {"a": 1}}, {"x": 5}); m["x"]`, 5},
+		{`let a = {"x": {"a": 1}}; deepMerge(a, {"x": {"a": 2}}); a["x"]["a"]`, 1},
+		{`deepMerge({}, 1)`, "second argument to `deepMerge` must be HASH, got INTEGER"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+
+	merged, ok := testEval(`deepMerge({"x": {"a": 1}}, {"x": {"b": 2}})["x"]`).(*object.Hash)
+	if !ok {
+		t.Fatalf("merged value is not Hash")
+	}
+	if len(merged.Pairs) != 2 {
+		t.Errorf("nested hash has wrong number of pairs. got=%d", len(merged.Pairs))
+	}
+}
+
+func TestPickAndOmitBuiltins(t *testing.T) {
+	sample := `let h = {"a": 1, "b": 2, "c": 3};`
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{sample + `pick(h, ["a", "c", "missing"])["a"]`, 1},
+		{sample + `pick(h, ["a", "c", "missing"])["c"]`, 3},
+		{sample + `omit(h, ["a"])["b"]`, 2},
+		{sample + `omit(h, ["a"])["c"]`, 3},
+		{sample + `pick(h, [fn(x) { x }])`, "unusable as hash key: FUNCTION"},
+		{`omit([], [])`, "first argument to `omit` must be HASH, got ARRAY"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+
+	sizes := []struct {
+		input    string
+		expected int
+	}{
+		{sample + `pick(h, ["a", "c", "missing"])`, 2},
+		{sample + `omit(h, ["a"])`, 2},
+		{sample + `omit(h, [])`, 3},
+		{sample + `h`, 3},
+	}
+	for _, tt := range sizes {
+		hash, ok := testEval(tt.input).(*object.Hash)
+		if !ok {
+			t.Errorf("object is not Hash for %q", tt.input)
+			continue
+		}
+		if len(hash.Pairs) != tt.expected {
+			t.Errorf("hash has wrong number of pairs. got=%d, want=%d", len(hash.Pairs), tt.expected)
+		}
+	}
+}
+
+func TestGetInBuiltin(t *testing.T) {
+	data := `let data = {"user": {"name": "ann", "roles": [10, 20]}, "count": 1};`
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{data + `getIn(data, ["user", "roles", 0])`, 10},
+		{data + `getIn(data, ["user", "roles", 1])`, 20},
+		{data + `getIn(data, ["count"])`, 1},
+		{data + `getIn(data, ["user", "roles", 2])`, nil},
+		{data + `getIn(data, ["user", "missing", 0])`, nil},
+		{data + `getIn(data, ["count", "deeper"])`, nil},
+		{data + `getIn(data, ["user", "roles", "0"])`, nil},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestSetInBuiltin(t *testing.T) {
+	data := `let data = {"user": {"name": "ann", "roles": [10, 20]}, "count": 1};`
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{data + `let d = setIn(data, ["user", "roles", 1], 99); getIn(d, ["user", "roles", 1])`, 99},
+		{data + `let d = setIn(data, ["user", "roles", 1], 99); getIn(data, ["user", "roles", 1])`, 20},
+		{data + `let d = setIn(data, ["user", "roles", 1], 99); getIn(d, ["user", "roles", 0])`, 10},
+		{data + `let d = setIn(data, ["user", "roles", 1], 99); d["count"]`, 1},
+		{data + `let d = setIn(data, ["new", "deep", "key"], 7); getIn(d, ["new", "deep", "key"])`, 7},
+		{data + `let d = setIn(data, ["count"], 2); d["count"]`, 2},
+		{data + `setIn(data, ["count", "x"], 2)`, "cannot set x inside INTEGER"},
+		{data + `setIn(data, ["user", "roles", "x"], 2)`, "cannot index ARRAY with STRING"},
+		{data + `setIn(data, ["user", "roles", 5], 2)`, "index out of range: 5"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestLogicalOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"true && true", true},
+		{"true && false", false},
+		{"false || true", true},
+		{"false || false", false},
+		{"1 < 2 && 2 < 3", true},
+		{"null || 0", true},
+		{"null && true", false},
+		// the right operand would be an error if it were evaluated
+		{"false && missing", false},
+		{"true || missing", true},
+		{"false && puts(1)", false},
+		{"let f = fn() { 1 / 0 }; true || f()", true},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+
+	errObj, ok := testEval("true && missing").(*object.Error)
+	if !ok {
+		t.Fatalf("right operand not evaluated when left is truthy")
+	}
+	if errObj.Message != "line 1: identifier not found: missing" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
+
+func TestParseIntBuiltin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`parseInt("ff", 16)`, 255},
+		{`parseInt("-101", 2)`, -5},
+		{`parseInt("z", 36)`, 35},
+		{`parseInt("42", 10)`, 42},
+		{`parseInt("12g", 16)`, `could not parse "12g" as base 16 integer`},
+		{`parseInt("1", 1)`, "base must be between 2 and 36, got 1"},
+		{`parseInt(1, 10)`, "first argument to `parseInt` must be STRING, got INTEGER"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestModuloOperator(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"10 % 3", 1},
+		{"9 % 3", 0},
+		{"2 + 10 % 4 * 3", 8},
+		// the result takes the sign of the left operand
+		{"-7 % 3", -1},
+		{"7 % -3", 1},
+		{"-7 % -3", -1},
+		{"5 % 0", "line 1: division by zero"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestFloorDivision(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"7 ~/ 2", 3},
+		{"6 ~/ 3", 2},
+		// rounds down rather than toward zero like /
+		{"-7 ~/ 2", -4},
+		{"7 ~/ -2", -4},
+		{"-7 ~/ -2", 3},
+		{"-6 ~/ 2", -3},
+		{"1 + 7 ~/ 2 * 2", 7},
+		{"7.5 ~/ 2", 3.0},
+		{"-7.5 ~/ 2.0", -4.0},
+		// // after a value stays a comment
+		{"let x = 10 // keep ten\nx", 10},
+		{"7 // 2", 7},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case float64:
+			testFloatObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestToBaseBuiltin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		isError  bool
+	}{
+		{`toBase(255, 16)`, "ff", false},
+		{`toBase(10, 2)`, "1010", false},
+		{`toBase(-35, 36)`, "-z", false},
+		{`toBase(parseInt("777", 8), 8)`, "777", false},
+		{`toBase(1.5, 2)`, "first argument to `toBase` must be INTEGER, got FLOAT", true},
+		{`toBase(10, 37)`, "base must be between 2 and 36, got 37", true},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if tt.isError {
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != tt.expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", tt.expected, errObj.Message)
+			}
+			continue
+		}
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Errorf("object is not String. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+		if str.Value != tt.expected {
+			t.Errorf("String has wrong value. got=%q, want=%q", str.Value, tt.expected)
+		}
+	}
+}
+
+func TestDivisionByZero(t *testing.T) {
+	tests := []string{"5 / 0", "let zero = 0; 10 / zero", "5 % 0", "5 ~/ 0", "5.0 ~/ 0.0"}
+	for _, input := range tests {
+		errObj, ok := testEval(input).(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q", input)
+			continue
+		}
+		if errObj.Message != "line 1: division by zero" {
+			t.Errorf("wrong error message. got=%q", errObj.Message)
+		}
+	}
+
+	// float division follows IEEE 754 instead of erroring
+	testFloatObject(t, testEval("5.0 / 0.0"), math.Inf(1))
+	testFloatObject(t, testEval("-5 / 0.0"), math.Inf(-1))
+	nan, ok := testEval("0.0 / 0").(*object.Float)
+	if !ok || !math.IsNaN(nan.Value) {
+		t.Errorf("0.0 / 0 is not NaN. got=%+v", nan)
+	}
+}
+
+func TestStringComparison(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`"foo" == "foo"`, true},
+		{`"foo" == "bar"`, false},
+		{`"foo" != "bar"`, true},
+		{`"foo" != "foo"`, false},
+		{`let a = "x"; let b = "x"; a == b`, true},
+		{`"ab" == "a" + "b"`, true},
+	}
+	for _, tt := range tests {
+		testBooleanObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestSignBuiltin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`sign(-42)`, -1},
+		{`sign(0)`, 0},
+		{`sign(7)`, 1},
+		{`sign(-0.5)`, -1},
+		{`sign(0.0)`, 0},
+		{`sign(2.5)`, 1},
+		{`sign("1")`, "argument to `sign` must be INTEGER or FLOAT, got STRING"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestHashLiterals(t *testing.T) {
+	input := `let two = "two";
+	{
+		"one": 10 - 9,
+		two: 1 + 1,
+		"thr" + "ee": 6 / 2,
+		4: 4,
+		true: 5,
+		false: 6
+	}`
+	evaluated := testEval(input)
+	result, ok := evaluated.(*object.Hash)
+	if !ok {
+		t.Fatalf("Eval didn't return Hash. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	expected := map[object.HashKey]int64{
+		(&object.String{Value: "one"}).HashKey():   1,
+		(&object.String{Value: "two"}).HashKey():   2,
+		(&object.String{Value: "three"}).HashKey(): 3,
+		(&object.Integer{Value: 4}).HashKey():      4,
+		TRUE.HashKey():                             5,
+		FALSE.HashKey():                            6,
+	}
+	if len(result.Pairs) != len(expected) {
+		t.Fatalf("Hash has wrong num of pairs. got=%d", len(result.Pairs))
+	}
+	for expectedKey, expectedValue := range expected {
+		pair, ok := result.Pairs[expectedKey]
+		if !ok {
+			t.Errorf("no pair for given key in Pairs")
+			continue
+		}
+		testIntegerObject(t, pair.Value, expectedValue)
+	}
+
+	errObj, ok := testEval(`{[1]: 2}`).(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned for unhashable key")
+	}
+	if errObj.Message != "unusable as hash key: ARRAY" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
+
+func TestHashKeys(t *testing.T) {
+	hello1 := &object.String{Value: "Hello World"}
+	hello2 := &object.String{Value: "Hello World"}
+	diff := &object.String{Value: "My name is johnny"}
+	if hello1.HashKey() != hello2.HashKey() {
+		t.Errorf("strings with same content have different hash keys")
+	}
+	if hello1.HashKey() == diff.HashKey() {
+		t.Errorf("strings with different content have same hash keys")
+	}
+	if (&object.Integer{Value: 1}).HashKey() == TRUE.HashKey() {
+		t.Errorf("keys of different types collide")
+	}
+}
+
+func TestFreezeDeepBuiltin(t *testing.T) {
+	input := `let data = {"list": [1, [2, 3]], "inner": {"x": 1}}; freezeDeep(data);`
+	evaluated := testEval(input)
+	hash, ok := evaluated.(*object.Hash)
+	if !ok {
+		t.Fatalf("object is not Hash. got=%T (%+v)", evaluated, evaluated)
+	}
+	if !hash.Frozen {
+		t.Errorf("top level hash is not frozen")
+	}
+	list := hash.Pairs[(&object.String{Value: "list"}).HashKey()].Value.(*object.Array)
+	if !list.Frozen {
+		t.Errorf("nested array is not frozen")
+	}
+	if !list.Elements[1].(*object.Array).Frozen {
+		t.Errorf("doubly nested array is not frozen")
+	}
+	inner := hash.Pairs[(&object.String{Value: "inner"}).HashKey()].Value.(*object.Hash)
+	if !inner.Frozen {
+		t.Errorf("nested hash is not frozen")
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let a = freezeDeep([[1, 2]]); let b = a[0]; b[0:1] = [9];", "ERROR: line 1: cannot modify frozen ARRAY"},
+		{"let h = freezeDeep({\"xs\": [1]}); let xs = h[\"xs\"]; xs[:] = [];", "ERROR: line 1: cannot modify frozen ARRAY"},
+		// copying builtins leave the frozen original alone
+		{"let a = freezeDeep([1]); push(a, 2); a", "[1]"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+
+	errObj, ok := testEval(`freezeDeep(1)`).(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned for non-container argument")
+	}
+	if errObj.Message != "argument to `freezeDeep` must be ARRAY or HASH, got INTEGER" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
+
+func TestHashIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`{"foo": 5}["foo"]`, 5},
+		{`{"foo": 5}["bar"]`, nil},
+		{`let key = "foo"; {"foo": 5}[key]`, 5},
+		{`{}["foo"]`, nil},
+		{`{5: 5}[5]`, 5},
+		{`{1: "a"}[2]`, nil},
+		{`{true: 5}[true]`, 5},
+		{`{false: 5}[false]`, 5},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+
+	str, ok := testEval(`{"name": "Monkey"}["name"]`).(*object.String)
+	if !ok || str.Value != "Monkey" {
+		t.Errorf("hash lookup returned wrong value. got=%+v", str)
+	}
+
+	errObj, ok := testEval(`{"name": "Monkey"}[fn(x) { x }]`).(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned for unhashable index")
+	}
+	if errObj.Message != "unusable as hash key: FUNCTION" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
+
+func TestOperatorMethods(t *testing.T) {
+	vec := `
+	let vec = fn(x, y) {
+		{
+			"x": x,
+			"y": y,
+			"__add__": fn(a, b) { vec(a["x"] + b["x"], a["y"] + b["y"]) },
+			"__sub__": fn(a, b) { vec(a["x"] - b["x"], a["y"] - b["y"]) },
+			"__eq__": fn(a, b) { a["x"] == b["x"] && a["y"] == b["y"] }
+		}
+	};`
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{vec + `(vec(1, 2) + vec(3, 4))["x"]`, 4},
+		{vec + `(vec(1, 2) + vec(3, 4))["y"]`, 6},
+		{vec + `(vec(5, 5) - vec(1, 2))["y"]`, 3},
+		{vec + `vec(1, 2) == vec(1, 2)`, true},
+		{vec + `vec(1, 2) == vec(2, 1)`, false},
+		{vec + `vec(1, 2) != vec(2, 1)`, true},
+		{vec + `vec(1, 2) != vec(1, 2)`, false},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		}
+	}
+
+	errObj, ok := testEval(`{"x": 1} * {"x": 2}`).(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned for operator without a method")
+	}
+	if errObj.Message != "line 1: unknown operator: HASH * HASH" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
+
+func TestStringIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"hello"[0]`, "h"},
+		{`"hello"[1]`, "e"},
+		{`"hello"[4]`, "o"},
+		{`let s = "hello"; s[len(s) - 1]`, "o"},
+		{`"hello"[5]`, nil},
+		{`"hello"[-1]`, nil},
+		{`""[0]`, nil},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		expected, ok := tt.expected.(string)
+		if !ok {
+			testNullObject(t, evaluated)
+			continue
+		}
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Errorf("object is not String. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+		if str.Value != expected {
+			t.Errorf("String has wrong value. got=%q, want=%q", str.Value, expected)
+		}
+	}
+}
+
+func TestSourceBuiltin(t *testing.T) {
+	evaluated := testEval(`source(fn(x){x+1})`)
+	str, ok := evaluated.(*object.String)
+	if !ok {
+		t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
+	}
+	if !strings.Contains(str.Value, "x + 1") {
+		t.Errorf("source does not contain body. got=%q", str.Value)
+	}
+	if !strings.HasPrefix(str.Value, "fn(x)") {
+		t.Errorf("source does not start with parameters. got=%q", str.Value)
+	}
+
+	errObj, ok := testEval(`source(len)`).(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned for non-function")
+	}
+	if errObj.Message != "argument to `source` must be FUNCTION, got BUILTIN" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
+
+func TestSliceExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"[1, 2, 3, 4][1:3]", []int64{2, 3}},
+		{"[1, 2, 3, 4][:2]", []int64{1, 2}},
+		{"[1, 2, 3, 4][2:]", []int64{3, 4}},
+		{"[1, 2, 3, 4][:]", []int64{1, 2, 3, 4}},
+		{"[1, 2, 3, 4][-5:10]", []int64{1, 2, 3, 4}},
+		{"[1, 2, 3, 4][3:1]", []int64{}},
+		{"[1, 2, 3, 4][-2:]", []int64{3, 4}},
+		{"[1, 2, 3, 4][:-1]", []int64{1, 2, 3}},
+		{"[1, 2, 3, 4][-3:-1]", []int64{2, 3}},
+		{"[1, 2, 3, 4][-1:-3]", []int64{}},
+		{`"hello"[1:3]`, "el"},
+		{`"hello"[:2]`, "he"},
+		{`"hello"[3:]`, "lo"},
+		{`"hello"[:]`, "hello"},
+		{`"hello"[2:100]`, "llo"},
+		{`"hello"[4:2]`, ""},
+		{`"hello"[-3:]`, "llo"},
+		{`"hello"[:-2]`, "hel"},
+		{`"hello"[-10:-4]`, "h"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case []int64:
+			arr, ok := evaluated.(*object.Array)
+			if !ok {
+				t.Errorf("object is not Array. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if len(arr.Elements) != len(expected) {
+				t.Errorf("wrong num of elements for %q. got=%d, want=%d", tt.input, len(arr.Elements), len(expected))
+				continue
+			}
+			for i, want := range expected {
+				testIntegerObject(t, arr.Elements[i], want)
+			}
+		case string:
+			str, ok := evaluated.(*object.String)
+			if !ok {
+				t.Errorf("object is not String. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if str.Value != expected {
+				t.Errorf("String has wrong value. got=%q, want=%q", str.Value, expected)
+			}
+		}
+	}
+
+	errObj, ok := testEval(`[1, 2]["a":]`).(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned for non-integer bound")
+	}
+	if errObj.Message != "line 1: slice bounds must be INTEGER, got STRING" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
+
+func TestImplicitFunctionResult(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"fn(x) { x + 1 }(1)", 2},
+		{"fn(x) { 1; 2; x + 1 }(1)", 2},
+		{"fn(x) { if (x > 0) { x } else { 0 } }(3)", 3},
+		{"fn(x) { let y = x + 1; }(1)", nil},
+		{"fn() { }()", nil},
+		{"let f = fn() { let a = 5; }; let r = f(); r", nil},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestPrefixedIntegerLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"0xff", 255},
+		{"0X1F", 31},
+		{"0b1010", 10},
+		{"0o17", 15},
+		{"0x10 + 0b11 * 0o2", 22},
+	}
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestStringConcatCoercion(t *testing.T) {
+	defer SetConfig(DefaultConfig())
+
+	errObj, ok := testEval(`"x" + 1`).(*object.Error)
+	if !ok {
+		t.Fatalf("strict mode did not return an error")
+	}
+	if errObj.Message != "line 1: type mismatch: STRING + INTEGER" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+
+	SetConfig(Config{IntegerBits: 64, CoerceStringConcat: true})
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"x" + 1`, "x1"},
+		{`"n=" + 5`, "n=5"},
+		{`1 + "x"`, "1x"},
+		{`"pi is " + 3.14`, "pi is 3.14"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Errorf("object is not String. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+		if str.Value != tt.expected {
+			t.Errorf("String has wrong value. got=%q, want=%q", str.Value, tt.expected)
+		}
+	}
+
+	errObj, ok = testEval(`"x" - 1`).(*object.Error)
+	if !ok {
+		t.Fatalf("coercion applied to an operator other than +")
+	}
+	if errObj.Message != "line 1: type mismatch: STRING - INTEGER" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
+
+func TestHashSpread(t *testing.T) {
+	base := `let base = {"a": 1, "b": 2};`
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{base + `{...base, "b": 20}["b"]`, 20},
+		{base + `{...base, "b": 20}["a"]`, 1},
+		{base + `{...base, "c": 3}["c"]`, 3},
+		{base + `{...base, ...{"a": 10}}["a"]`, 10},
+		{base + `let h = {...base, "b": 20}; base["b"]`, 2},
+		{`{...[1, 2]}`, "line 1: cannot spread ARRAY into a hash"},
+		{"let x = 1;\n{\"a\": 1, ...x}", "line 2: cannot spread INTEGER into a hash"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+
+	hash, ok := testEval(base + `{...base, "b": 20, "c": 3}`).(*object.Hash)
+	if !ok {
+		t.Fatalf("object is not Hash")
+	}
+	if len(hash.Pairs) != 3 {
+		t.Errorf("hash has wrong number of pairs. got=%d, want=3", len(hash.Pairs))
+	}
+}
+
+func TestStrictIndexing(t *testing.T) {
+	defer SetConfig(DefaultConfig())
+
+	lenient := []string{"[1, 2, 3][3]", "[1, 2, 3][-1]", `"abc"[3]`}
+	for _, input := range lenient {
+		testNullObject(t, testEval(input))
+	}
+
+	SetConfig(Config{IntegerBits: 64, StrictIndexing: true})
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"[1, 2, 3][3]", "index out of range: 3 (len 3)"},
+		{"[1, 2, 3][-1]", "index out of range: -1 (len 3)"},
+		{"[][0]", "index out of range: 0 (len 0)"},
+		{`"abc"[3]`, "index out of range: 3 (len 3)"},
+	}
+	for _, tt := range tests {
+		errObj, ok := testEval(tt.input).(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q", tt.input)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q",
+				tt.expectedMessage, errObj.Message)
+		}
+	}
+	testIntegerObject(t, testEval("[1, 2, 3][2]"), 3)
+}
+
+func TestSortedEntriesBuiltin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`sortedEntries({"b": 2, "a": 1})`, "[[a, 1], [b, 2]]"},
+		{`sortedEntries({})`, "[]"},
+		{`sortedEntries({"1": "s", 2: "i", true: "b", 1: "n"})`, "[[1, n], [1, s], [2, i], [true, b]]"},
+		{`sortedEntries([1])`, "ERROR: argument to `sortedEntries` must be HASH, got ARRAY"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s - wrong result. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestTimesBuiltin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`times(3, fn(i) { i * i })`, "[0, 1, 4]"},
+		{`times(0, fn(i) { i })`, "[]"},
+		{`times(-2, fn(i) { i })`, "[]"},
+		{`let n = 0; times(4, fn(i) { n += i }); n`, "6"},
+		{`times(2, len)`, "ERROR: argument to `len` not supported, got INTEGER"},
+		{`times(2, 1)`, "ERROR: second argument to `times` must be FUNCTION, got INTEGER"},
+		{`times("3", fn(i) { i })`, "ERROR: first argument to `times` must be INTEGER, got STRING"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s - wrong result. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestAssertEqualBuiltin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`assertEqual(1, 1)`, ""},
+		{`assertEqual([1, "a", {"k": [true]}], [1, "a", {"k": [true]}])`, ""},
+		{`assertEqual(null, null)`, ""},
+		{`assertEqual(1, 2)`, "assertEqual failed: expected 2, got 1"},
+		{`assertEqual(1, "1")`, `assertEqual failed: expected STRING "1", got INTEGER 1`},
+		{`assertEqual(1, 1.0)`, "assertEqual failed: expected FLOAT 1, got INTEGER 1"},
+		{`assertEqual([1, 2], [1, 3])`, "assertEqual failed: at [1]: expected 3, got 2"},
+		{`assertEqual([1], [1, 2])`, "assertEqual failed: expected ARRAY of length 2, got length 1"},
+		{`assertEqual({"a": [1]}, {"a": [2]})`, `assertEqual failed: at ["a"][0]: expected 2, got 1`},
+		{`assertEqual({}, {"a": 1})`, `assertEqual failed: missing key "a"`},
+		{`assertEqual({"a": 1, "b": 2}, {"a": 1})`, `assertEqual failed: unexpected key "b"`},
+		{`let f = fn() { 1 }; assertEqual(f, f)`, ""},
+		{`assertEqual(fn() { 1 }, fn() { 1 })`, "assertEqual failed: expected fn() {\n1\n}, got fn() {\n1\n}"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if tt.expected == "" {
+			testNullObject(t, evaluated)
+			continue
+		}
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("%s - no error object returned. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expected, errObj.Message)
+		}
+	}
+}
+
+func TestMeasureBuiltin(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	calls := 0
+	SetClock(func() time.Time {
+		calls++
+		if calls == 1 {
+			return start
+		}
+		return start.Add(1500 * time.Microsecond)
+	})
+	defer SetClock(time.Now)
+
+	evaluated := testEval(`measure(fn() { 6 * 7 })`)
+	arr, ok := evaluated.(*object.Array)
+	if !ok {
+		t.Fatalf("object is not Array. got=%T (%+v)", evaluated, evaluated)
+	}
+	if len(arr.Elements) != 2 {
+		t.Fatalf("wrong number of elements. got=%d", len(arr.Elements))
+	}
+	testIntegerObject(t, arr.Elements[0], 42)
+	testFloatObject(t, arr.Elements[1], 1.5)
+
+	errObj, ok := testEval(`measure(1)`).(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned for a non-function")
+	}
+	if errObj.Message != "argument to `measure` must be FUNCTION, got INTEGER" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
+
+func TestBuiltinsBuiltin(t *testing.T) {
+	RegisterBuiltin("hostOnly", func(args ...object.Object) object.Object { return NULL })
+	defer delete(builtins, "hostOnly")
+
+	evaluated := testEval(`builtins()`)
+	arr, ok := evaluated.(*object.Array)
+	if !ok {
+		t.Fatalf("object is not Array. got=%T (%+v)", evaluated, evaluated)
+	}
+	names := map[string]bool{}
+	for _, el := range arr.Elements {
+		names[el.(*object.String).Value] = true
+	}
+	for _, want := range []string{"len", "puts", "builtins", "hostOnly"} {
+		if !names[want] {
+			t.Errorf("builtins() is missing %q", want)
+		}
+	}
+	if len(arr.Elements) != len(builtins) {
+		t.Errorf("wrong number of names. got=%d, want=%d", len(arr.Elements), len(builtins))
+	}
+	testIntegerObject(t, testEval(`hostOnly(); 1`), 1)
+
+	errObj, ok := testEval(`builtins(1)`).(*object.Error)
+	if !ok || errObj.Message != "wrong number of arguments. got=1, want=0" {
+		t.Errorf("wrong result for builtins(1). got=%+v", errObj)
+	}
+}
+
+func TestTypeBuiltin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`type(1)`, "INTEGER"},
+		{`type(1.5)`, "FLOAT"},
+		{`type(true)`, "BOOLEAN"},
+		{`type("s")`, "STRING"},
+		{`type(null)`, "NULL"},
+		{`type([1])`, "ARRAY"},
+		{`type({})`, "HASH"},
+		{`type(fn(x) { x })`, "FUNCTION"},
+		{`type(len)`, "BUILTIN"},
+		{`type(type(1))`, "STRING"},
+		{`type()`, "ERROR: wrong number of arguments. got=0, want=1"},
+		{`type(1, 2)`, "ERROR: wrong number of arguments. got=2, want=1"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s - wrong result. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestPairsBuiltin(t *testing.T) {
+	evaluated := testEval(`pairs({1: "int", "1": "str", true: "bool"})`)
+	arr, ok := evaluated.(*object.Array)
+	if !ok {
+		t.Fatalf("object is not Array. got=%T (%+v)", evaluated, evaluated)
+	}
+	expected := []struct {
+		keyType object.ObjectType
+		value   string
+	}{
+		{object.INTEGER_OBJ, "int"},
+		{object.STRING_OBJ, "str"},
+		{object.BOOLEAN_OBJ, "bool"},
+	}
+	if len(arr.Elements) != len(expected) {
+		t.Fatalf("wrong number of pairs. got=%d", len(arr.Elements))
+	}
+	for i, want := range expected {
+		pair := arr.Elements[i].(*object.Array)
+		if pair.Elements[0].Type() != want.keyType {
+			t.Errorf("pairs[%d] key has wrong type. expected=%s, got=%s", i, want.keyType, pair.Elements[0].Type())
+		}
+		if pair.Elements[1].Inspect() != want.value {
+			t.Errorf("pairs[%d] has wrong value. expected=%q, got=%q", i, want.value, pair.Elements[1].Inspect())
+		}
+	}
+
+	errObj, ok := testEval(`pairs([1])`).(*object.Error)
+	if !ok || errObj.Message != "argument to `pairs` must be HASH, got ARRAY" {
+		t.Errorf("wrong result for pairs([1]). got=%+v", errObj)
+	}
+}
+
+func TestEqualityOnCyclicStructures(t *testing.T) {
+	one := &object.Integer{Value: 1}
+	a := &object.Array{Elements: []object.Object{one}}
+	a.Elements = append(a.Elements, a)
+	b := &object.Array{Elements: []object.Object{one}}
+	b.Elements = append(b.Elements, b)
+	if !objectsEqual(a, b) {
+		t.Errorf("matching cyclic arrays are not equal")
+	}
+
+	c := &object.Array{Elements: []object.Object{&object.Integer{Value: 2}}}
+	c.Elements = append(c.Elements, c)
+	if diff := objectsDiff(a, c, ""); diff != "at [0]: expected 2, got 1" {
+		t.Errorf("wrong diff for differing cyclic arrays. got=%q", diff)
+	}
+
+	h := &object.Hash{Pairs: map[object.HashKey]object.HashPair{}}
+	key := &object.String{Value: "self"}
+	h.Pairs[key.HashKey()] = object.HashPair{Key: key, Value: h}
+	g := &object.Hash{Pairs: map[object.HashKey]object.HashPair{}}
+	g.Pairs[key.HashKey()] = object.HashPair{Key: key, Value: g}
+	if !objectsEqual(h, g) {
+		t.Errorf("matching cyclic hashes are not equal")
+	}
+}
+
+func TestConversionBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`int("42")`, 42},
+		{`int("-7")`, -7},
+		{`int(3.9)`, 3},
+		{`int(-3.9)`, -3},
+		{`int(5)`, 5},
+		{`int(true)`, 1},
+		{`int(false)`, 0},
+		{`int("abc")`, "ERROR: cannot convert \"abc\" to INTEGER"},
+		{`int("4.2")`, "ERROR: cannot convert \"4.2\" to INTEGER"},
+		{`int(1.0 / 0)`, "ERROR: cannot convert +Inf to INTEGER"},
+		{`int([1])`, "ERROR: argument to `int` not supported, got ARRAY"},
+		{`int()`, "ERROR: wrong number of arguments. got=0, want=1"},
+		{`str(10)`, `"10"`},
+		{`str(true)`, `"true"`},
+		{`str(2.5)`, `"2.5"`},
+		{`str(null)`, `"null"`},
+		{`str("s")`, `"s"`},
+		{`str([1, "a"])`, `"[1, a]"`},
+		{`str(1, 2)`, "ERROR: wrong number of arguments. got=2, want=1"},
+		{`bool(0)`, false},
+		{`bool(1)`, true},
+		{`bool("")`, false},
+		{`bool("a")`, true},
+		{`bool(0.0)`, false},
+		{`bool([])`, false},
+		{`bool({"a": 1})`, true},
+		{`bool(null)`, false},
+		{`bool(false)`, false},
+		{`bool(fn() { 1 })`, true},
+		{`bool()`, "ERROR: wrong number of arguments. got=0, want=1"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			got := evaluated.Inspect()
+			if str, ok := evaluated.(*object.String); ok {
+				got = str.Quoted()
+			}
+			if got != expected {
+				t.Errorf("%s - wrong result. expected=%s, got=%s", tt.input, expected, got)
+			}
+		}
+	}
+}
+
+func TestSliceAssignment(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let arr = [1, 2, 3, 4]; arr[1:3] = ["x", "y"]; arr`, "[1, x, y, 4]"},
+		// the replacement may be a different length than the range
+		{`let arr = [1, 2, 3, 4]; arr[1:3] = [9]; arr`, "[1, 9, 4]"},
+		{`let arr = [1, 2]; arr[1:1] = [7, 8]; arr`, "[1, 7, 8, 2]"},
+		{`let arr = [1, 2, 3]; arr[:] = []; arr`, "[]"},
+		{`let arr = [1, 2]; arr[5:] = [3]; arr`, "[1, 2, 3]"},
+		// other references see the change
+		{`let arr = [1, 2, 3]; let alias = arr; arr[0:1] = [0]; alias`, "[0, 2, 3]"},
+		{`let s = "abc"; s[0:1] = ["z"]`, "ERROR: line 1: slice assignment not supported: STRING"},
+		{`let arr = [1]; arr[0:1] = 5`, "ERROR: line 1: slice assignment value must be ARRAY, got INTEGER"},
+		{`let arr = [1]; arr["a":] = [2]`, "ERROR: line 1: slice bounds must be INTEGER, got STRING"},
+		{`let arr = freezeDeep([1, 2]); arr[0:1] = [3]`, "ERROR: line 1: cannot modify frozen ARRAY"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s - wrong result. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestSplitBuiltin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+		err      string
+	}{
+		{input: `split("a,b,c", ",")`, expected: []string{"a", "b", "c"}},
+		{input: `split("a, b", ", ")`, expected: []string{"a", "b"}},
+		{input: `split("abc", ";")`, expected: []string{"abc"}},
+		{input: `split("a,,b,", ",")`, expected: []string{"a", "", "b", ""}},
+		{input: `split("héj", "")`, expected: []string{"h", "é", "j"}},
+		{input: `split("", ",")`, expected: []string{""}},
+		{input: `split("a")`, err: "wrong number of arguments. got=1, want=2"},
+		{input: `split(1, ",")`, err: "first argument to `split` must be STRING, got INTEGER"},
+		{input: `split("a", 1)`, err: "second argument to `split` must be STRING, got INTEGER"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if tt.err != "" {
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != tt.err {
+				t.Errorf("wrong error message. expected=%q, got=%q", tt.err, errObj.Message)
+			}
+			continue
+		}
+		arr, ok := evaluated.(*object.Array)
+		if !ok {
+			t.Errorf("object is not Array. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+		if len(arr.Elements) != len(tt.expected) {
+			t.Errorf("%s - wrong number of pieces. expected=%d, got=%d", tt.input, len(tt.expected), len(arr.Elements))
+			continue
+		}
+		for i, want := range tt.expected {
+			str, ok := arr.Elements[i].(*object.String)
+			if !ok || str.Value != want {
+				t.Errorf("%s - piece %d wrong. expected=%q, got=%+v", tt.input, i, want, arr.Elements[i])
+			}
+		}
+	}
+}
+
+func TestJoinBuiltin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`join(["a", "b", "c"], ",")`, `"a,b,c"`},
+		{`join([], "-")`, `""`},
+		{`join(["solo"], "-")`, `"solo"`},
+		// non-string elements are joined by their inspected form
+		{`join([1, 2, 3], "-")`, `"1-2-3"`},
+		{`join([1, "a", true, null, [2]], " ")`, `"1 a true null [2]"`},
+		{`join(split("a,b", ","), ";")`, `"a;b"`},
+		{`join("abc", ",")`, "ERROR: first argument to `join` must be ARRAY, got STRING"},
+		{`join([1], 1)`, "ERROR: second argument to `join` must be STRING, got INTEGER"},
+		{`join([1])`, "ERROR: wrong number of arguments. got=1, want=2"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		got := evaluated.Inspect()
+		if str, ok := evaluated.(*object.String); ok {
+			got = str.Quoted()
+		}
+		if got != tt.expected {
+			t.Errorf("%s - wrong result. expected=%s, got=%s", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestMapBuiltin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`map([1, 2, 3], fn(x) { x * 2 })`, "[2, 4, 6]"},
+		{`map([], fn(x) { x })`, "[]"},
+		{`map(["a", [1, 2]], len)`, "[1, 2]"},
+		{`let a = [1, 2]; map(a, fn(x) { x + 1 }); a`, "[1, 2]"},
+		{`map([1, "a", 3], fn(x) { x * 2 })`, "ERROR: line 1: type mismatch: STRING * INTEGER"},
+		{`map([1], fn(x, y) { x })`, "ERROR: wrong number of arguments. got=1, want=2"},
+		{`map([1], 2)`, "ERROR: second argument to `map` must be FUNCTION, got INTEGER"},
+		{`map(1, fn(x) { x })`, "ERROR: first argument to `map` must be ARRAY, got INTEGER"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s - wrong result. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestFilterBuiltin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`filter([1, 2, 3, 4], fn(x) { x % 2 == 0 })`, "[2, 4]"},
+		{`filter([], fn(x) { true })`, "[]"},
+		// non-boolean results follow the same truthiness as if
+		{`filter([1, 2, 3], fn(x) { if (x > 1) { x } })`, "[2, 3]"},
+		{`filter([0, "", null, false], fn(x) { x })`, `[0, ]`},
+		{`filter([1, "a"], fn(x) { x > 0 })`, "ERROR: line 1: type mismatch: STRING > INTEGER"},
+		{`filter([1], "f")`, "ERROR: second argument to `filter` must be FUNCTION, got STRING"},
+		{`filter({}, fn(x) { x })`, "ERROR: first argument to `filter` must be ARRAY, got HASH"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s - wrong result. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestCachedIntegers(t *testing.T) {
+	if testEval("5") != testEval("2 + 3") {
+		t.Errorf("small integers are not shared")
+	}
+	if testEval("-128") != testEval("-100 - 28") {
+		t.Errorf("smallest cached integer is not shared")
+	}
+	testIntegerObject(t, testEval("255"), 255)
+	if testEval("1000") == testEval("1000") {
+		t.Errorf("integers outside the cache are shared")
+	}
+	testBooleanObject(t, testEval("let a = 7; let b = 3 + 4; a == b"), true)
+	testBooleanObject(t, testEval("1000 == 999 + 1"), true)
+}
+
+// an integer-heavy loop whose values stay in the cached range. sharing
+// small integers took this from 1809 to 6 allocs/op
+func BenchmarkSmallIntegerLoop(b *testing.B) {
+	program := parser.New(lexer.New("let n = 0; for (let i = 0; i < 200; i += 1) { n = i % 7 * 2 + 1 }; n")).ParseProgram()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Eval(program, object.NewEnviroment())
+	}
+}
+
+func TestReduceBuiltin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`reduce([1, 2, 3, 4], 0, fn(acc, x) { acc + x })`, "10"},
+		{`reduce(["a", "b", "c"], "", fn(acc, s) { acc + s })`, "abc"},
+		// elements are visited left to right
+		{`reduce([1, 2, 3], 0, fn(acc, x) { acc * 10 + x })`, "123"},
+		{`reduce([], 42, fn(acc, x) { acc + x })`, "42"},
+		{`reduce([1, 2], [], push)`, "[1, 2]"},
+		{`reduce([1, true], 0, fn(acc, x) { acc + x })`, "ERROR: line 1: type mismatch: INTEGER + BOOLEAN"},
+		// like any call, arguments past the parameters are ignored
+		{`reduce([1], 0, fn(acc) { acc })`, "0"},
+		{`reduce([1], 0)`, "ERROR: wrong number of arguments. got=2, want=3"},
+		{`reduce([1], 0, 1)`, "ERROR: third argument to `reduce` must be FUNCTION, got INTEGER"},
+		{`reduce("ab", 0, fn(acc, x) { acc })`, "ERROR: first argument to `reduce` must be ARRAY, got STRING"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s - wrong result. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestRangeBuiltin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`range(3)`, "[0, 1, 2]"},
+		{`range(0)`, "[]"},
+		{`range(-2)`, "[]"},
+		{`range(2, 5)`, "[2, 3, 4]"},
+		// descending bounds are empty unless a negative step is given
+		{`range(5, 2)`, "[]"},
+		{`range(5, 2, -1)`, "[5, 4, 3]"},
+		{`range(0, 10, 3)`, "[0, 3, 6, 9]"},
+		{`range(0, 3, -1)`, "[]"},
+		{`let sum = 0; for (i in range(1, 5)) { sum += i }; sum`, "10"},
+		{`range(0, 3, 0)`, "ERROR: step of `range` must not be 0"},
+		{`range("3")`, "ERROR: arguments to `range` must be INTEGER, got STRING"},
+		{`range()`, "ERROR: wrong number of arguments. got=0, want=1 to 3"},
+		{`range(1, 2, 3, 4)`, "ERROR: wrong number of arguments. got=4, want=1 to 3"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s - wrong result. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestZipHashBuiltin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`sortedEntries(zipHash(["a", "b"], [1, 2]))`, "[[a, 1], [b, 2]]"},
+		{`let h = zipHash(["a", "b"], [1, 2]); h["b"]`, "2"},
+		// the longer array is truncated
+		{`sortedEntries(zipHash(["a", "b", "c"], [1]))`, "[[a, 1]]"},
+		{`sortedEntries(zipHash([1, true], ["x", "y", "z"]))`, "[[1, x], [true, y]]"},
+		{`sortedEntries(zipHash(["k", "k"], [1, 2]))`, "[[k, 2]]"},
+		{`zipHash([], [])`, "{}"},
+		{`zipHash([[1]], [1])`, "ERROR: unusable as hash key: ARRAY"},
+		{`zipHash("a", [1])`, "ERROR: first argument to `zipHash` must be ARRAY, got STRING"},
+		{`zipHash(["a"], 1)`, "ERROR: second argument to `zipHash` must be ARRAY, got INTEGER"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s - wrong result. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestKeysAndValuesBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`keys({"b": 2, "a": 1, "c": 3})`, "[a, b, c]"},
+		// values follow the sorted order of their keys
+		{`values({"b": 2, "a": 1, "c": 3})`, "[1, 2, 3]"},
+		{`keys({})`, "[]"},
+		{`values({})`, "[]"},
+		{`type(keys({1: "x"})[0])`, "INTEGER"},
+		{`keys([1])`, "ERROR: argument to `keys` must be HASH, got ARRAY"},
+		{`values("a")`, "ERROR: argument to `values` must be HASH, got STRING"},
+		{`keys({}, {})`, "ERROR: wrong number of arguments. got=2, want=1"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s - wrong result. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestInvertBuiltin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`sortedEntries(invert({"a": 1, "b": 2}))`, "[[1, a], [2, b]]"},
+		{`invert({"a": 1})[1]`, "a"},
+		{`invert({})`, "{}"},
+		// on a repeated value the key that sorts last wins
+		{`sortedEntries(invert({"a": 1, "b": 1, "c": 2}))`, "[[1, b], [2, c]]"},
+		{`invert({"a": [1]})`, "ERROR: unusable as hash key: ARRAY"},
+		{`invert([1])`, "ERROR: argument to `invert` must be HASH, got ARRAY"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s - wrong result. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestDeleteBuiltin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`sortedEntries(delete({"a": 1, "b": 2, "c": 3}, "b"))`, "[[a, 1], [c, 3]]"},
+		{`delete({"a": 1}, "a")["a"]`, "null"},
+		{`sortedEntries(delete({1: "x", "1": "y"}, 1))`, "[[1, y]]"},
+		// a missing key leaves an equal copy
+		{`sortedEntries(delete({"a": 1}, "z"))`, "[[a, 1]]"},
+		{`let h = {"a": 1}; delete(h, "a"); h["a"]`, "1"},
+		{`delete({"a": 1}, [1])`, "ERROR: unusable as hash key: ARRAY"},
+		{`delete([1], 0)`, "ERROR: first argument to `delete` must be HASH, got ARRAY"},
+		{`delete({"a": 1})`, "ERROR: wrong number of arguments. got=1, want=2"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s - wrong result. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestRetryBuiltin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let n = 0; let f = fn() { n += 1; if (n < 3) { 1 + true } else { "ok" } }; retry(f, 5)`, "ok"},
+		{`let n = 0; let f = fn() { n += 1; if (n < 3) { 1 + true } else { n } }; retry(f, 5); n`, "3"},
+		// every attempt failing returns the last error
+		{`let n = 0; retry(fn() { n += 1; -"x" }, 2)`, "ERROR: line 1: unknown operator: -STRING"},
+		{`let n = 0; let f = fn() { n += 1; if (n < 3) { 1 + true } else { n } }; retry(f, 3)`, "3"},
+		{`retry(fn() { 1 }, 0)`, "ERROR: `retry` needs at least 1 attempt, got 0"},
+		{`retry(1, 2)`, "ERROR: first argument to `retry` must be FUNCTION, got INTEGER"},
+		{`retry(fn() { 1 }, "2")`, "ERROR: second argument to `retry` must be INTEGER, got STRING"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s - wrong result. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestContainsBuiltin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`contains([1, 2, 3], 2)`, true},
+		{`contains([1, 2, 3], 4)`, false},
+		{`contains(["a", "b"], "b")`, true},
+		{`contains([true], false)`, false},
+		{`contains([[1, 2], {"k": 1}], [1, 2])`, true},
+		{`contains([null], null)`, true},
+		{`contains([], 1)`, false},
+		// elements must match in type as well as value
+		{`contains([1], "1")`, false},
+		{`contains([1], 1.0)`, false},
+		{`contains({"a": 1}, "a")`, true},
+		{`contains({"a": 1}, 1)`, false},
+		{`contains({1: "a"}, 1)`, true},
+		{`contains({"a": 1}, [1])`, "unusable as hash key: ARRAY"},
+		{`contains("abc", "a")`, "first argument to `contains` must be ARRAY or HASH, got STRING"},
+		{`contains([1])`, "wrong number of arguments. got=1, want=2"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestMemoizeCacheBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let calls = 0; let sq = memoize(fn(x) { calls += 1; x * x }); sq(3) + sq(3) + sq(4)`, "34"},
+		{`let calls = 0; let sq = memoize(fn(x) { calls += 1; x * x }); sq(3); sq(3); sq(4); calls`, "2"},
+		{`let sq = memoize(fn(x) { x * x }); sq(1); sq(2); sq(2); cacheSize(sq)`, "2"},
+		{`let sq = memoize(fn(x) { x * x }); sq(1); sq(2); cacheClear(sq); cacheSize(sq)`, "0"},
+		{`let calls = 0; let sq = memoize(fn(x) { calls += 1; x }); sq(1); cacheClear(sq); sq(1); calls`, "2"},
+		// the same value with different types caches separately
+		{`let id = memoize(fn(x) { type(x) }); id(1); id("1"); cacheSize(id)`, "2"},
+		{`let add = memoize(fn(a, b) { a + b }); add(1, 2); add(2, 1); cacheSize(add)`, "2"},
+		// errors are not cached
+		{`let n = 0; let f = memoize(fn(x) { n += 1; if (n == 1) { -true } else { x } }); retry(fn() { f(5) }, 2); n`, "2"},
+		{`map([1, 2, 2], memoize(fn(x) { x * 10 }))`, "[10, 20, 20]"},
+		{`memoize(fn(x) { x })([1])`, "ERROR: unusable as memoize key: ARRAY"},
+		{`memoize(1)`, "ERROR: argument to `memoize` must be FUNCTION, got INTEGER"},
+		{`cacheSize(fn(x) { x })`, "ERROR: argument to `cacheSize` must be MEMOIZED, got FUNCTION"},
+		{`cacheClear(len)`, "ERROR: argument to `cacheClear` must be MEMOIZED, got BUILTIN"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s - wrong result. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestAbsMinMaxBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`abs(-5)`, 5},
+		{`abs(5)`, 5},
+		{`abs(0)`, 0},
+		{`abs(-2.5)`, 2.5},
+		{`max(3, 9, 1)`, 9},
+		{`min(3, 9, 1)`, 1},
+		{`max(4)`, 4},
+		{`min(-1, -7)`, -7},
+		// one float among the arguments makes the result a float
+		{`max(1, 2.5)`, 2.5},
+		{`max(3, 2.5)`, 3.0},
+		{`min(2, 0.5, 1)`, 0.5},
+		{`abs("1")`, "argument to `abs` must be INTEGER or FLOAT, got STRING"},
+		{`min()`, "`min` needs at least 1 argument"},
+		{`max()`, "`max` needs at least 1 argument"},
+		{`max(1, "2")`, "arguments to `max` must be INTEGER or FLOAT, got STRING"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case float64:
+			testFloatObject(t, evaluated, expected)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	res := Analyze("let x = 1 + 2; x * 3")
+	if res.Tokens != 10 {
+		t.Errorf("wrong token count. expected=10, got=%d", res.Tokens)
+	}
+	if res.Nodes != 10 {
+		t.Errorf("wrong node count. expected=10, got=%d", res.Nodes)
+	}
+	if len(res.Errors) != 0 {
+		t.Errorf("expected no errors, got=%v", res.Errors)
+	}
+	testIntegerObject(t, res.Value, 9)
+
+	res = Analyze("let = 5;")
+	if len(res.Errors) == 0 {
+		t.Fatalf("expected parser errors, got none")
+	}
+	first := res.Errors[0]
+	if first.Line != 1 || first.Column != 5 {
+		t.Errorf("wrong error position. expected=1:5, got=%d:%d", first.Line, first.Column)
+	}
+	if first.Message != "expected next token to be IDENTIFIER, got = instead" {
+		t.Errorf("wrong error message. got=%q", first.Message)
+	}
+	if res.Tokens != 4 {
+		t.Errorf("wrong token count. expected=4, got=%d", res.Tokens)
+	}
+	if res.Value != nil {
+		t.Errorf("program with errors should not run, got=%v", res.Value)
+	}
+}
+
+func TestSortBuiltin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`sort([3, 1, 2])`, "[1, 2, 3]"},
+		{`sort([2.5, 1, -3])`, "[-3, 1, 2.5]"},
+		{`sort(["pear", "apple", "fig"])`, "[apple, fig, pear]"},
+		{`sort([])`, "[]"},
+		// the input is left alone
+		{`let a = [2, 1]; sort(a); a`, "[2, 1]"},
+		{`sort([3, 1, 2], fn(a, b) { b - a })`, "[3, 2, 1]"},
+		{`sort(["ccc", "a", "bb"], fn(a, b) { len(a) - len(b) })`, "[a, bb, ccc]"},
+		{`sort([1, "a"])`, "ERROR: cannot sort INTEGER together with STRING"},
+		{`sort([true, false])`, "ERROR: `sort` needs numbers or strings without a comparator, got BOOLEAN"},
+		{`sort(1)`, "ERROR: first argument to `sort` must be ARRAY, got INTEGER"},
+		{`sort([1, 2], 3)`, "ERROR: second argument to `sort` must be FUNCTION, got INTEGER"},
+		{`sort([1, 2], fn(a, b) { true })`, "ERROR: comparator passed to `sort` must return INTEGER, got BOOLEAN"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestDisabledBuiltins(t *testing.T) {
+	defer SetConfig(DefaultConfig())
+
+	SetConfig(Config{IntegerBits: 64, DisabledBuiltins: []string{"getenv", "read"}})
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`getenv("HOME")`, "ERROR: line 1: identifier not found: getenv"},
+		{`read("x.txt")`, "ERROR: line 1: identifier not found: read"},
+		{`len("abc")`, "3"},
+		{`contains(builtins(), "getenv")`, "false"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+
+	SetConfig(Config{IntegerBits: 64, AllowedBuiltins: []string{"len"}})
+	if got := testEval(`len([1, 2])`).Inspect(); got != "2" {
+		t.Errorf("allowed builtin should still work, got=%s", got)
+	}
+	if got := testEval(`puts("hi")`).Inspect(); got != "ERROR: line 1: identifier not found: puts" {
+		t.Errorf("builtin outside the allowlist should be unresolvable, got=%s", got)
+	}
+}
+
+func TestReverseBuiltin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`reverse([1, 2, 3])`, "[3, 2, 1]"},
+		{`reverse([])`, "[]"},
+		{`let a = [1, 2]; reverse(a); a`, "[1, 2]"},
+		{`reverse("abc")`, "cba"},
+		{`reverse("")`, ""},
+		{`reverse("héllo")`, "olléh"},
+		{`reverse(1)`, "ERROR: argument to `reverse` must be ARRAY or STRING, got INTEGER"},
+		{`reverse([1], [2])`, "ERROR: wrong number of arguments. got=2, want=1"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+// a filesystem held in a map, for running IO builtins without touching disk
+type memFileSystem map[string]string
+
+func (fs memFileSystem) ReadFile(name string) ([]byte, error) {
+	data, ok := fs[name]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return []byte(data), nil
+}
+
+func (fs memFileSystem) WriteFile(name string, data []byte) error {
+	fs[name] = string(data)
+	return nil
+}
+
+func TestIOContext(t *testing.T) {
+	defer SetIO(DefaultIO())
+
+	var out bytes.Buffer
+	fs := memFileSystem{"greeting.txt": "hello"}
+	SetIO(IOContext{In: strings.NewReader("world\n"), Out: &out, Err: &out, FS: fs})
+
+	evaluated := testEval(`
+let name = input("name? ");
+let text = read("greeting.txt") + ", " + name;
+write("out.txt", text);
+puts(text);
+`)
+	testNullObject(t, evaluated)
+	if out.String() != "name? hello, world\n" {
+		t.Errorf("wrong output. got=%q", out.String())
+	}
+	if fs["out.txt"] != "hello, world" {
+		t.Errorf("wrong file contents. got=%q", fs["out.txt"])
+	}
+
+	errObj, ok := testEval(`read("missing.txt")`).(*object.Error)
+	if !ok || errObj.Message != "could not read missing.txt: file does not exist" {
+		t.Errorf("expected a read error, got=%v", errObj)
+	}
+
+	SetIO(IOContext{In: strings.NewReader(""), Out: &out, Err: &out})
+	for _, input := range []string{`read("greeting.txt")`, `write("x", "y")`} {
+		errObj, ok := testEval(input).(*object.Error)
+		if !ok || !strings.HasSuffix(errObj.Message, "is disabled") {
+			t.Errorf("%s: expected the builtin to be disabled, got=%v", input, errObj)
+		}
+	}
+}
+
+func TestSetIODefaultsNilFields(t *testing.T) {
+	defer SetIO(DefaultIO())
+
+	var out bytes.Buffer
+	SetIO(IOContext{Out: &out})
+	ctx := GetIO()
+	if ctx.In == nil || ctx.Err == nil {
+		t.Fatalf("nil reader or writer was kept. got=%+v", ctx)
+	}
+	if ctx.FS != nil {
+		t.Errorf("a nil filesystem should stay nil to keep read and write off")
+	}
+	testNullObject(t, testEval(`let len = 1; puts("x")`))
+	if out.String() != "x\n" {
+		t.Errorf("wrong output. got=%q", out.String())
+	}
+}
+
+func TestPrintBuiltin(t *testing.T) {
+	defer SetIO(DefaultIO())
+
+	var out bytes.Buffer
+	SetIO(IOContext{In: strings.NewReader(""), Out: &out, Err: &out})
+
+	testNullObject(t, testEval(`print("a", 1); print([2]); puts("b"); print(true)`))
+	if out.String() != "a1[2]b\ntrue" {
+		t.Errorf("wrong output. got=%q", out.String())
+	}
+}
+
+func TestSetOutput(t *testing.T) {
+	defer SetIO(DefaultIO())
+
+	var out bytes.Buffer
+	SetOutput(&out)
+	testNullObject(t, testEval(`puts("hi")`))
+	if out.String() != "hi\n" {
+		t.Errorf("wrong output. expected=%q, got=%q", "hi\n", out.String())
+	}
+}
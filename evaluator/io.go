@@ -0,0 +1,83 @@
+package evaluator
+
+import (
+	"bufio"
+	"io"
+	"os"
+)
+
+// files the `read` and `write` builtins go through
+type FileSystem interface {
+	ReadFile(name string) ([]byte, error)
+	WriteFile(name string, data []byte) error
+}
+
+// the real filesystem
+type osFileSystem struct{}
+
+func (osFileSystem) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+func (osFileSystem) WriteFile(name string, data []byte) error {
+	return os.WriteFile(name, data, 0644)
+}
+
+// everything IO builtins touch, hosts can swap it to run a program against
+// in-memory buffers or with the filesystem taken away
+type IOContext struct {
+	In  io.Reader
+	Out io.Writer
+	// warnings go here, hosts running scripts send error messages here too
+	Err io.Writer
+	// nil turns `read` and `write` off
+	FS FileSystem
+}
+
+// stdin, stdout, stderr and the real filesystem
+func DefaultIO() IOContext {
+	return IOContext{In: os.Stdin, Out: os.Stdout, Err: os.Stderr, FS: osFileSystem{}}
+}
+
+var ioContext = DefaultIO()
+
+// buffered over ioContext.In, shared by `input` and `lines` so neither loses
+// what the other read ahead
+var inputReader = bufio.NewReader(ioContext.In)
+
+// replaces the IO context, a nil reader or writer falls back to the default
+func SetIO(ctx IOContext) {
+	def := DefaultIO()
+	if ctx.In == nil {
+		ctx.In = def.In
+	}
+	if ctx.Out == nil {
+		ctx.Out = def.Out
+	}
+	if ctx.Err == nil {
+		ctx.Err = def.Err
+	}
+	ioContext = ctx
+	inputReader = bufio.NewReader(ctx.In)
+}
+
+func GetIO() IOContext {
+	return ioContext
+}
+
+// replaces the writer `puts` and `print` write to
+func SetOutput(w io.Writer) {
+	ioContext.Out = w
+}
+
+// replaces the writer warnings go to
+func SetErrorOutput(w io.Writer) {
+	ioContext.Err = w
+}
+
+// replaces the reader `input` and `lines` read from, a *bufio.Reader is used
+// as it is so the caller can keep reading from it too
+func SetInput(r io.Reader) {
+	ioContext.In = r
+	inputReader = bufio.NewReader(r)
+}
@@ -1,23 +1,47 @@
 package lexer
 
-import "interpreter/token"
+import (
+	"interpreter/token"
+	"strconv"
+	"strings"
+)
 
 type Lexer struct {
 	input        string
 	position     int
 	readPosition int
 	ch           byte
+	line         int         // line of ch, starting at 1
+	column       int         // column of ch within its line, starting at 1
+	prev         token.Token // last token returned
+	newlines     bool        // emit NEWLINE tokens where a line break ends a statement
+	brackets     []byte      // open ( [ and { when emitting newlines, innermost last
 }
 
 // returns a pointer to a new Lexer
 func New(input string) *Lexer {
-	l := &Lexer{input: input}
+	l := &Lexer{input: input, line: 1}
 	l.readChar()
 	return l
 }
 
+// returns a Lexer that also emits a NEWLINE token when a line break follows
+// something that can end a statement, letting newlines stand in for semicolons
+func NewWithNewlines(input string) *Lexer {
+	l := New(input)
+	l.newlines = true
+	return l
+}
+
 // moves the poistion of the char "up-one"
 func (l *Lexer) readChar() {
+	if l.ch == '\n' {
+		l.line++
+		l.column = 0
+	}
+	if l.readPosition <= len(l.input) { // column stays put once EOF is reached
+		l.column++
+	}
 	if l.readPosition >= len(l.input) {
 		l.ch = 0
 	} else {
@@ -27,16 +51,84 @@ func (l *Lexer) readChar() {
 	l.readPosition += 1
 }
 
-// returns what the next token is
+// returns what the next token is, positioned at its first character
 func (l *Lexer) NextToken() token.Token {
-	var tok token.Token
+	startLine := l.line
 	l.skipWhitespace()
+	for l.ch == '/' && (l.peakchar() == '/' || l.peakchar() == '*') {
+		line, column := l.line, l.column
+		if l.peakchar() == '/' {
+			l.skipLineComment()
+		} else if !l.skipBlockComment() {
+			return token.Token{Type: token.ILLEGAL, Literal: "unterminated block comment", Line: line, Column: column}
+		}
+		l.skipWhitespace()
+	}
+
+	line, column := l.line, l.column
+	if l.newlines && line > startLine && l.endsStatement() {
+		l.prev = token.Token{Type: token.NEWLINE, Literal: "\n", Line: line, Column: column}
+		return l.prev
+	}
+	tok := l.readToken()
+	tok.Line, tok.Column = line, column
+	l.prev = tok
+	if l.newlines {
+		l.trackBrackets(tok.Type)
+	}
+	return tok
+}
+
+// reports whether a line break just skipped should become a NEWLINE token.
+// breaks inside ( and [ never do, nor ones before a closing bracket or
+// comma, so lists and hash literals can still span lines. a { or else
+// opening the next line continues an if or fn header from the line above
+func (l *Lexer) endsStatement() bool {
+	if n := len(l.brackets); n > 0 && l.brackets[n-1] != '{' {
+		return false
+	}
+	switch l.ch {
+	case '}', ')', ']', ',', '{', 0:
+		return false
+	}
+	if l.nextWordIs("else") {
+		return false
+	}
+	switch l.prev.Type {
+	case token.INT, token.FLOAT, token.STRING, token.IDENTIFIER, token.TRUE, token.FALSE,
+		token.NULL, token.RP, token.RSB, token.RB, token.BREAK, token.CONTINUE:
+		return true
+	}
+	return false
+}
+
+// reports whether the input at the current char is word and not just the
+// start of a longer identifier
+func (l *Lexer) nextWordIs(word string) bool {
+	rest := l.input[l.position:]
+	if !strings.HasPrefix(rest, word) {
+		return false
+	}
+	return len(rest) == len(word) || !isLetter(rest[len(word)]) && !isDigit(rest[len(word)])
+}
+
+func (l *Lexer) trackBrackets(t token.TokenType) {
+	switch t {
+	case token.LP, token.LSB, token.LB:
+		l.brackets = append(l.brackets, t[0])
+	case token.RP, token.RSB, token.RB:
+		if n := len(l.brackets); n > 0 {
+			l.brackets = l.brackets[:n-1]
+		}
+	}
+}
+
+func (l *Lexer) readToken() token.Token {
+	var tok token.Token
 	switch l.ch {
 	case '=':
 		if l.peakchar() == '=' {
-			ch := l.ch
-			l.readChar()
-			tok = token.Token{Type: token.EQ, Literal: string(ch) + string(l.ch)}
+			tok = l.newTwoCharToken(token.EQ)
 		} else {
 			tok = newToken(token.ASSIGN, l.ch)
 		}
@@ -49,49 +141,107 @@ func (l *Lexer) NextToken() token.Token {
 	case ',':
 		tok = newToken(token.COMMA, l.ch)
 	case '+':
-		tok = newToken(token.PLUS, l.ch)
+		if l.peakchar() == '=' {
+			tok = l.newTwoCharToken(token.PLUSEQ)
+		} else {
+			tok = newToken(token.PLUS, l.ch)
+		}
 	case '{':
 		tok = newToken(token.LB, l.ch)
 	case '}':
 		tok = newToken(token.RB, l.ch)
 	case '-':
-		tok = newToken(token.MINUS, l.ch)
+		if l.peakchar() == '=' {
+			tok = l.newTwoCharToken(token.MINUSEQ)
+		} else {
+			tok = newToken(token.MINUS, l.ch)
+		}
 	case '/':
-		tok = newToken(token.SLASH, l.ch)
+		if l.peakchar() == '=' {
+			tok = l.newTwoCharToken(token.SLASHEQ)
+		} else {
+			tok = newToken(token.SLASH, l.ch)
+		}
+	case '~':
+		// ~/ is floor division, // is taken by comments
+		if l.peakchar() == '/' {
+			tok = l.newTwoCharToken(token.FLOORDIV)
+		} else {
+			tok = newToken(token.ILLEGAL, l.ch)
+		}
 	case '*':
-		tok = newToken(token.STAR, l.ch)
+		if l.peakchar() == '=' {
+			tok = l.newTwoCharToken(token.STAREQ)
+		} else {
+			tok = newToken(token.STAR, l.ch)
+		}
+	case '%':
+		tok = newToken(token.PERCENT, l.ch)
 	case '>':
-		tok = newToken(token.GR, l.ch)
+		if l.peakchar() == '=' {
+			tok = l.newTwoCharToken(token.GREATEREQ)
+		} else {
+			tok = newToken(token.GR, l.ch)
+		}
 	case '<':
-		tok = newToken(token.LE, l.ch)
+		if l.peakchar() == '=' {
+			tok = l.newTwoCharToken(token.LESSEQ)
+		} else {
+			tok = newToken(token.LE, l.ch)
+		}
 	case '!':
 		if l.peakchar() == '=' {
-			ch := l.ch
-			l.readChar()
-			tok = token.Token{Type: token.NEQ, Literal: string(ch) + string(l.ch)}
+			tok = l.newTwoCharToken(token.NEQ)
 		} else {
 			tok = newToken(token.EXCLA, l.ch)
 		}
+	case '&':
+		if l.peakchar() == '&' {
+			tok = l.newTwoCharToken(token.AND)
+		} else {
+			tok = newToken(token.ILLEGAL, l.ch)
+		}
+	case '|':
+		if l.peakchar() == '|' {
+			tok = l.newTwoCharToken(token.OR)
+		} else {
+			tok = newToken(token.ILLEGAL, l.ch)
+		}
 	case 0:
 		tok.Literal = ""
 		tok.Type = token.EOF
 	case '"':
+		str, ok := l.readString()
+		if !ok {
+			return token.Token{Type: token.ILLEGAL, Literal: "unterminated string"}
+		}
 		tok.Type = token.STRING
-		tok.Literal = l.readString()
+		tok.Literal = str
 	case '[':
 		tok = newToken(token.LSB, l.ch)
 	case ']':
 		tok = newToken(token.RSB, l.ch)
 	case ':':
 		tok = newToken(token.COLON, l.ch)
+	case '.':
+		if l.peakchar() == '.' && l.readPosition+1 < len(l.input) && l.input[l.readPosition+1] == '.' {
+			l.readChar()
+			l.readChar()
+			tok = token.Token{Type: token.ELLIPSIS, Literal: "..."}
+			break
+		}
+		if isDigit(l.peakchar()) {
+			tok.Literal, tok.Type = l.readNumber()
+			return tok
+		}
+		tok = newToken(token.ILLEGAL, l.ch)
 	default:
 		if isLetter(l.ch) {
 			tok.Literal = l.readIdentifier()
 			tok.Type = token.LookupIdent(tok.Literal)
 			return tok
 		} else if isDigit(l.ch) {
-			tok.Literal = l.readNumber()
-			tok.Type = token.INT
+			tok.Literal, tok.Type = l.readNumber()
 			return tok
 		} else {
 			tok = newToken(token.ILLEGAL, l.ch)
@@ -101,36 +251,168 @@ func (l *Lexer) NextToken() token.Token {
 	return tok
 }
 
-func (l *Lexer) readString() string {
-	position := l.position + 1
+// reads a string literal, decoding \n \t \r \\ \" \xHH and \uHHHH escapes.
+// returns false if EOF is reached before an unescaped closing quote
+func (l *Lexer) readString() (string, bool) {
+	var out strings.Builder
 	for {
 		l.readChar()
-		if l.ch == '"' || l.ch == 0 {
-			break
+		if l.ch == '"' {
+			return out.String(), true
+		}
+		if l.ch == 0 {
+			return out.String(), false
+		}
+		if l.ch != '\\' {
+			out.WriteByte(l.ch)
+			continue
+		}
+		l.readChar()
+		switch l.ch {
+		case 'n':
+			out.WriteByte('\n')
+		case 't':
+			out.WriteByte('\t')
+		case 'r':
+			out.WriteByte('\r')
+		case '\\', '"':
+			out.WriteByte(l.ch)
+		case 'x':
+			if v, ok := l.readHexDigits(2); ok {
+				out.WriteByte(byte(v))
+			}
+		case 'u':
+			if v, ok := l.readHexDigits(4); ok {
+				out.WriteRune(rune(v))
+			}
+		case 0:
+			return out.String(), false
+		default:
+			out.WriteByte('\\')
+			out.WriteByte(l.ch)
 		}
 	}
-	return l.input[position:l.position]
+}
+
+// consumes n hex digits following the current char
+func (l *Lexer) readHexDigits(n int) (uint64, bool) {
+	if l.readPosition+n > len(l.input) {
+		return 0, false
+	}
+	v, err := strconv.ParseUint(l.input[l.readPosition:l.readPosition+n], 16, 32)
+	if err != nil {
+		return 0, false
+	}
+	for i := 0; i < n; i++ {
+		l.readChar()
+	}
+	return v, true
+}
+
+// single byte strings built once, so operator and delimiter tokens don't
+// allocate a new literal each time
+var charLiterals [256]string
+
+func init() {
+	for i := range charLiterals {
+		charLiterals[i] = string([]byte{byte(i)})
+	}
 }
 
 func newToken(tokenType token.TokenType, ch byte) token.Token {
-	return token.Token{Type: tokenType, Literal: string(ch)}
+	return token.Token{Type: tokenType, Literal: charLiterals[ch]}
+}
+
+// consumes the second char of a two char operator, the literal is sliced
+// from the input rather than built
+func (l *Lexer) newTwoCharToken(tokenType token.TokenType) token.Token {
+	start := l.position
+	l.readChar()
+	return token.Token{Type: tokenType, Literal: l.input[start:l.readPosition]}
 }
 
-// returns the string that's the current token
+// returns the string that's the current token, digits may follow the first
+// letter so names like x1 and base64 are one identifier
 func (l *Lexer) readIdentifier() string {
 	position := l.position
-	for isLetter(l.ch) {
+	for isLetter(l.ch) || isDigit(l.ch) {
 		l.readChar()
 	}
 	return l.input[position:l.position]
 }
 
-func (l *Lexer) readNumber() string {
+// reads an INT, or a FLOAT when a '.' is followed by digits. a trailing
+// dot as in `1.` is not part of the number, a second fraction as in `1.2.3`
+// makes the whole literal ILLEGAL
+func (l *Lexer) readNumber() (string, token.TokenType) {
+	if l.ch == '0' {
+		if name, ok := prefixedBases[l.peakchar()]; ok {
+			return l.readPrefixedNumber(name)
+		}
+	}
 	position := l.position
 	for isDigit(l.ch) {
 		l.readChar()
 	}
-	return l.input[position:l.position]
+	if l.ch != '.' || !isDigit(l.peakchar()) {
+		return l.input[position:l.position], token.INT
+	}
+	l.readChar()
+	for isDigit(l.ch) {
+		l.readChar()
+	}
+	if l.ch == '.' && isDigit(l.peakchar()) {
+		for l.ch == '.' || isDigit(l.ch) {
+			l.readChar()
+		}
+		return "invalid float literal " + l.input[position:l.position], token.ILLEGAL
+	}
+	return l.input[position:l.position], token.FLOAT
+}
+
+// names of the bases selected by the char after a leading 0
+var prefixedBases = map[byte]string{
+	'x': "hexadecimal", 'X': "hexadecimal",
+	'o': "octal", 'O': "octal",
+	'b': "binary", 'B': "binary",
+}
+
+// reads a 0x, 0o or 0b literal, returning an ILLEGAL token if a digit
+// doesn't belong to the base
+func (l *Lexer) readPrefixedNumber(name string) (string, token.TokenType) {
+	position := l.position
+	l.readChar()
+	l.readChar()
+	digits := l.position
+	for isLetter(l.ch) || isDigit(l.ch) {
+		l.readChar()
+	}
+	literal := l.input[position:l.position]
+	if l.position == digits || !validDigits(l.input[digits:l.position], name) {
+		return "invalid " + name + " literal " + literal, token.ILLEGAL
+	}
+	return literal, token.INT
+}
+
+func validDigits(digits string, name string) bool {
+	for i := 0; i < len(digits); i++ {
+		ch := digits[i]
+		switch name {
+		case "binary":
+			if ch != '0' && ch != '1' {
+				return false
+			}
+		case "octal":
+			if ch < '0' || ch > '7' {
+				return false
+			}
+		case "hexadecimal":
+			if !isDigit(ch) && !('a' <= ch && ch <= 'f') && !('A' <= ch && ch <= 'F') {
+				return false
+			}
+		}
+	}
+	return true
 }
 
 func isLetter(ch byte) bool {
@@ -147,10 +429,32 @@ func (l *Lexer) skipWhitespace() {
 	}
 }
 
+// skips from `//` up to, but not including, the end of the line
+func (l *Lexer) skipLineComment() {
+	for l.ch != '\n' && l.ch != 0 {
+		l.readChar()
+	}
+}
+
+// skips a /* ... */ comment, returning false if EOF comes before the closing */
+func (l *Lexer) skipBlockComment() bool {
+	l.readChar()
+	l.readChar()
+	for l.ch != 0 {
+		if l.ch == '*' && l.peakchar() == '/' {
+			l.readChar()
+			l.readChar()
+			return true
+		}
+		l.readChar()
+	}
+	return false
+}
+
+// returns the char after the current one without consuming it
 func (l *Lexer) peakchar() byte {
-	if l.position < len(l.input)-1 {
-		ch := l.input[l.position+1]
-		return ch
+	if l.readPosition >= len(l.input) {
+		return 0
 	}
-	return 0
+	return l.input[l.readPosition]
 }
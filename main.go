@@ -7,7 +7,17 @@ import (
 )
 
 func main() {
-	fmt.Printf("This is Monkey Language\n")
-	fmt.Printf("Type any commands\n")
-	repl.Start(os.Stdin, os.Stdout)
+	if len(os.Args) > 1 {
+		if _, err := repl.RunFile(os.Args[1], os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	repl.StartWithConfig(repl.Config{
+		Prompt:      repl.PROMPT,
+		In:          os.Stdin,
+		Out:         os.Stdout,
+		ShowWelcome: true,
+	})
 }
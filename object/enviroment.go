@@ -25,6 +25,18 @@ func (e *Enviroment) Get(name string) (Object, bool) {
 	return obj, ok
 }
 
+// updates name in the scope that defines it, reporting false if no scope does
+func (e *Enviroment) Assign(name string, val Object) (Object, bool) {
+	if _, ok := e.store[name]; ok {
+		e.store[name] = val
+		return val, true
+	}
+	if e.outer != nil {
+		return e.outer.Assign(name, val)
+	}
+	return nil, false
+}
+
 func (e *Enviroment) Set(name string, val Object) Object {
 	e.store[name] = val
 	return val
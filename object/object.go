@@ -3,23 +3,35 @@ package object
 import (
 	"bytes"
 	"fmt"
+	"hash/fnv"
 	"interpreter/ast"
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 const (
 	INTEGER_OBJ      = "INTEGER"
+	FLOAT_OBJ        = "FLOAT"
 	BOOLEAN_OBJ      = "BOOLEAN"
 	NULL_OBJ         = "NULL"
 	RETURN_VALUE_OBJ = "RETURN VALUE"
+	BREAK_OBJ        = "BREAK"
+	CONTINUE_OBJ     = "CONTINUE"
 	ERROR_OBJ        = "ERROR"
 	FUNCTION_OBJ     = "FUNCTION"
 	STRING_OBJ       = "STRING"
 	BUILTIN_OBJ      = "BUILTIN"
+	MEMOIZED_OBJ     = "MEMOIZED"
 	ARRAY_OBJ        = "ARRAY"
 	HASH_OBJ         = "HASH"
 )
 
+// how deeply nested arrays and hashes are printed before Inspect gives up
+// and writes "..." instead
+var MaxInspectDepth = 32
+
 type ObjectType string
 type BuiltinFunction func(args ...Object) Object
 
@@ -28,6 +40,18 @@ type Object interface {
 	Inspect() string
 }
 
+// identifies a hash key by type and value, so equal keys held in
+// different objects find the same entry
+type HashKey struct {
+	Type  ObjectType
+	Value uint64
+}
+
+// implemented by objects that can be used as hash keys
+type Hashable interface {
+	HashKey() HashKey
+}
+
 type Builtin struct {
 	Fn BuiltinFunction
 }
@@ -41,6 +65,16 @@ type Integer struct {
 
 func (i *Integer) Inspect() string  { return fmt.Sprintf("%d", i.Value) }
 func (i *Integer) Type() ObjectType { return INTEGER_OBJ }
+func (i *Integer) HashKey() HashKey {
+	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
+}
+
+type Float struct {
+	Value float64
+}
+
+func (f *Float) Inspect() string  { return strconv.FormatFloat(f.Value, 'f', -1, 64) }
+func (f *Float) Type() ObjectType { return FLOAT_OBJ }
 
 type Boolean struct {
 	Value bool
@@ -48,6 +82,13 @@ type Boolean struct {
 
 func (b *Boolean) Inspect() string  { return fmt.Sprintf("%t", b.Value) }
 func (b *Boolean) Type() ObjectType { return BOOLEAN_OBJ }
+func (b *Boolean) HashKey() HashKey {
+	var value uint64
+	if b.Value {
+		value = 1
+	}
+	return HashKey{Type: b.Type(), Value: value}
+}
 
 type Null struct{}
 
@@ -61,6 +102,23 @@ type ReturnValue struct {
 func (rv *ReturnValue) Inspect() string  { return rv.Value.Inspect() }
 func (rv *ReturnValue) Type() ObjectType { return RETURN_VALUE_OBJ }
 
+// signals a break out of the loop with Label, or the nearest loop if empty
+type Break struct {
+	Label string
+}
+
+func (b *Break) Inspect() string  { return strings.TrimSpace("break " + b.Label) }
+func (b *Break) Type() ObjectType { return BREAK_OBJ }
+
+// signals a skip to the next iteration of the loop with Label, or the
+// nearest loop if empty
+type Continue struct {
+	Label string
+}
+
+func (c *Continue) Inspect() string  { return strings.TrimSpace("continue " + c.Label) }
+func (c *Continue) Type() ObjectType { return CONTINUE_OBJ }
+
 type Error struct {
 	Message string
 }
@@ -96,6 +154,21 @@ func (f *Function) Type() ObjectType {
 	return FUNCTION_OBJ
 }
 
+// a function wrapped by `memoize`, results are cached per argument list
+type Memoized struct {
+	Fn    Object
+	Cache map[string]Object
+}
+
+func (m *Memoized) Inspect() string  { return "memoized " + m.Fn.Inspect() }
+func (m *Memoized) Type() ObjectType { return MEMOIZED_OBJ }
+
+// number of argument lists with a cached result
+func (m *Memoized) CacheSize() int { return len(m.Cache) }
+
+// forgets every cached result
+func (m *Memoized) ClearCache() { m.Cache = map[string]Object{} }
+
 type String struct {
 	Value string
 }
@@ -108,8 +181,46 @@ func (s *String) Inspect() string {
 	return s.Value
 }
 
+func (s *String) HashKey() HashKey {
+	h := fnv.New64a()
+	h.Write([]byte(s.Value))
+	return HashKey{Type: s.Type(), Value: h.Sum64()}
+}
+
+// returns the value as a string literal that lexes back to the same value
+func (s *String) Quoted() string {
+	var out bytes.Buffer
+	out.WriteByte('"')
+	for i := 0; i < len(s.Value); {
+		r, size := utf8.DecodeRuneInString(s.Value[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			fmt.Fprintf(&out, "\\x%02x", s.Value[i])
+		case r == '"' || r == '\\':
+			out.WriteByte('\\')
+			out.WriteRune(r)
+		case r == '\n':
+			out.WriteString("\\n")
+		case r == '\t':
+			out.WriteString("\\t")
+		case r == '\r':
+			out.WriteString("\\r")
+		case r < utf8.RuneSelf && !unicode.IsPrint(r):
+			fmt.Fprintf(&out, "\\x%02x", r)
+		case !unicode.IsPrint(r) && r <= 0xFFFF:
+			fmt.Fprintf(&out, "\\u%04x", r)
+		default:
+			out.WriteString(s.Value[i : i+size])
+		}
+		i += size
+	}
+	out.WriteByte('"')
+	return out.String()
+}
+
 type Array struct {
 	Elements []Object
+	Frozen   bool // set by freezeDeep, mutating operations must refuse frozen arrays
 }
 
 func (a *Array) Type() ObjectType {
@@ -117,11 +228,21 @@ func (a *Array) Type() ObjectType {
 }
 
 func (a *Array) Inspect() string {
+	return a.inspect(0, map[Object]bool{})
+}
+
+func (a *Array) inspect(depth int, seen map[Object]bool) string {
+	if depth >= MaxInspectDepth || seen[a] {
+		return "..."
+	}
+	seen[a] = true
+	defer delete(seen, a)
+
 	var out bytes.Buffer
 	out.WriteString("[")
 	elmts := []string{}
 	for _, ele := range a.Elements {
-		elmts = append(elmts, ele.Inspect())
+		elmts = append(elmts, inspectNested(ele, depth+1, seen))
 	}
 
 	out.WriteString(strings.Join(elmts, ", "))
@@ -130,8 +251,15 @@ func (a *Array) Inspect() string {
 	return out.String()
 }
 
+// keeps the original key object next to its value so keys can be listed
+type HashPair struct {
+	Key   Object
+	Value Object
+}
+
 type Hash struct {
-	Pairs map[Object]Object
+	Pairs  map[HashKey]HashPair
+	Frozen bool // set by freezeDeep, mutating operations must refuse frozen hashes
 }
 
 func (h *Hash) Type() ObjectType {
@@ -139,13 +267,36 @@ func (h *Hash) Type() ObjectType {
 }
 
 func (h *Hash) Inspect() string {
+	return h.inspect(0, map[Object]bool{})
+}
+
+func (h *Hash) inspect(depth int, seen map[Object]bool) string {
+	if depth >= MaxInspectDepth || seen[h] {
+		return "..."
+	}
+	seen[h] = true
+	defer delete(seen, h)
+
 	var out bytes.Buffer
 	out.WriteString("{")
 	pairs := []string{}
-	for key, value := range h.Pairs {
-		pairs = append(pairs, key.Inspect()+":"+value.Inspect())
+	for _, pair := range h.Pairs {
+		pairs = append(pairs, pair.Key.Inspect()+":"+inspectNested(pair.Value, depth+1, seen))
 	}
 	out.WriteString(strings.Join(pairs, ", "))
 	out.WriteString("}")
 	return out.String()
 }
+
+// inspects a value inside an array or hash, carrying the depth and the
+// containers currently being printed so cycles end in "..."
+func inspectNested(obj Object, depth int, seen map[Object]bool) string {
+	switch obj := obj.(type) {
+	case *Array:
+		return obj.inspect(depth, seen)
+	case *Hash:
+		return obj.inspect(depth, seen)
+	default:
+		return obj.Inspect()
+	}
+}
@@ -16,6 +16,8 @@ type (
 const (
 	_ int = iota
 	LOWEST
+	LOGICALOR   // ||
+	LOGICALAND  // &&
 	EQUALS      // ==
 	LESSGREATER // > or <
 	SUM         // +
@@ -25,17 +27,39 @@ const (
 	INDEX
 )
 
+// tokens that turn `name <op> value` into an assignment
+var assignOperators = map[token.TokenType]bool{
+	token.ASSIGN:  true,
+	token.PLUSEQ:  true,
+	token.MINUSEQ: true,
+	token.STAREQ:  true,
+	token.SLASHEQ: true,
+}
+
 var precedences = map[token.TokenType]int{
-	token.EQ:    EQUALS,
-	token.NEQ:   EQUALS,
-	token.LE:    LESSGREATER,
-	token.GR:    LESSGREATER,
-	token.PLUS:  SUM,
-	token.MINUS: SUM,
-	token.SLASH: PRODUCT,
-	token.STAR:  PRODUCT,
-	token.LP:    CALL,
-	token.LSB:   INDEX,
+	token.OR:        LOGICALOR,
+	token.AND:       LOGICALAND,
+	token.EQ:        EQUALS,
+	token.NEQ:       EQUALS,
+	token.LE:        LESSGREATER,
+	token.GR:        LESSGREATER,
+	token.LESSEQ:    LESSGREATER,
+	token.GREATEREQ: LESSGREATER,
+	token.PLUS:      SUM,
+	token.MINUS:     SUM,
+	token.SLASH:     PRODUCT,
+	token.STAR:      PRODUCT,
+	token.PERCENT:   PRODUCT,
+	token.FLOORDIV:  PRODUCT,
+	token.LP:        CALL,
+	token.LSB:       INDEX,
+}
+
+// a parser error with the position of the token it was found at
+type ParseError struct {
+	Line    int
+	Column  int
+	Message string
 }
 
 type Parser struct {
@@ -43,6 +67,7 @@ type Parser struct {
 	curToken  token.Token
 	peakToken token.Token
 	errors    []string
+	details   []ParseError
 
 	prefixParseFns map[token.TokenType]prefixParseFns
 	infixParseFns  map[token.TokenType]infixParseFns
@@ -60,22 +85,31 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
 	p.registerPrefix(token.FUNC, p.parseFunction)
 	p.registerPrefix(token.IF, p.parseIfExpression)
+	p.registerPrefix(token.DO, p.parseDoExpression)
 	p.registerPrefix(token.LP, p.parseGroupExpressions)
 	p.registerPrefix(token.IDENTIFIER, p.parseIdentifier)
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
 	p.registerPrefix(token.EXCLA, p.parsePrefixExpression)
 	p.registerPrefix(token.TRUE, p.parseBoolean)
 	p.registerPrefix(token.FALSE, p.parseBoolean)
+	p.registerPrefix(token.NULL, p.parseNull)
 	p.registerInfix(token.LP, p.parseCallExpression)
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
 	p.registerInfix(token.MINUS, p.parseInfixExpression)
 	p.registerInfix(token.SLASH, p.parseInfixExpression)
 	p.registerInfix(token.STAR, p.parseInfixExpression)
+	p.registerInfix(token.PERCENT, p.parseInfixExpression)
+	p.registerInfix(token.FLOORDIV, p.parseInfixExpression)
 	p.registerInfix(token.EQ, p.parseInfixExpression)
 	p.registerInfix(token.NEQ, p.parseInfixExpression)
 	p.registerInfix(token.LE, p.parseInfixExpression)
 	p.registerInfix(token.GR, p.parseInfixExpression)
+	p.registerInfix(token.LESSEQ, p.parseInfixExpression)
+	p.registerInfix(token.AND, p.parseInfixExpression)
+	p.registerInfix(token.OR, p.parseInfixExpression)
+	p.registerInfix(token.GREATEREQ, p.parseInfixExpression)
 	p.registerInfix(token.LSB, p.parseIndexExpression)
 
 	return p
@@ -102,15 +136,47 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseLetStatement()
 	case token.RETURN:
 		return p.parseReturnStatement()
+	case token.WHILE:
+		return p.parseWhileStatement()
+	case token.FOR:
+		return p.parseForStatement()
+	case token.BREAK:
+		stmt := &ast.BreakStatement{Token: p.curToken}
+		stmt.Label = p.parseLoopLabel()
+		if p.peekEndsStatement() {
+			p.nextToken()
+		}
+		return stmt
+	case token.CONTINUE:
+		stmt := &ast.ContinueStatement{Token: p.curToken}
+		stmt.Label = p.parseLoopLabel()
+		if p.peekEndsStatement() {
+			p.nextToken()
+		}
+		return stmt
+	case token.SEMICOLON, token.NEWLINE:
+		// a lone semicolon or newline is an empty statement, nothing to add
+		return nil
+	case token.IDENTIFIER:
+		if p.peekTokenIs(token.COLON) {
+			return p.parseLabeledLoop()
+		}
+		if assignOperators[p.peakToken.Type] {
+			return p.parseAssignStatement()
+		}
+		return p.parseExpreesionStatement()
 	default:
 		return p.parseExpreesionStatement()
 	}
 }
 
-func (p *Parser) parseExpreesionStatement() *ast.ExpressionStatement {
+func (p *Parser) parseExpreesionStatement() ast.Statement {
 	stmt := &ast.ExpressionStatement{Token: p.curToken}
 	stmt.Expression = p.parseExpression(LOWEST)
-	if p.peekTokenIs(token.SEMICOLON) {
+	if slice, ok := stmt.Expression.(*ast.SliceExpression); ok && p.peekTokenIs(token.ASSIGN) {
+		return p.parseSliceAssignStatement(slice)
+	}
+	if p.peekEndsStatement() {
 		p.nextToken()
 	}
 
@@ -126,6 +192,10 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 	}
 
 	leftExp := prefix()
+	if leftExp == nil {
+		// the prefix parser already recorded why, an infix would only get a nil operand
+		return nil
+	}
 
 	for !p.peekTokenIs(token.SEMICOLON) && precedence < p.peekPrecedence() {
 		infix := p.infixParseFns[p.peakToken.Type]
@@ -149,6 +219,14 @@ func (p *Parser) parseHashExpression() ast.Expression {
 	hash.Pairs = make(map[ast.Expression]ast.Expression)
 	for !p.peekTokenIs(token.RB) {
 		p.nextToken()
+		if p.curTokenIs(token.ELLIPSIS) {
+			p.nextToken()
+			hash.Spreads = append(hash.Spreads, p.parseExpression(LOWEST))
+			if !p.peekTokenIs(token.RB) && !p.expectPeek(token.COMMA) {
+				return nil
+			}
+			continue
+		}
 		key := p.parseExpression(LOWEST)
 		if !p.expectPeek(token.COLON) {
 			return nil
@@ -210,11 +288,17 @@ func (p *Parser) parseFunctionParameters() []*ast.Identifier {
 		return idents
 	}
 
+	if !p.checkParameterName() {
+		return nil
+	}
 	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 	idents = append(idents, ident)
 	for p.peekTokenIs(token.COMMA) {
 		p.nextToken()
 		p.nextToken()
+		if !p.checkParameterName() {
+			return nil
+		}
 		ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 		idents = append(idents, ident)
 	}
@@ -225,6 +309,28 @@ func (p *Parser) parseFunctionParameters() []*ast.Identifier {
 	return idents
 }
 
+func (p *Parser) checkParameterName() bool {
+	if p.curTokenIs(token.IDENTIFIER) {
+		return true
+	}
+	if isKeywordToken(p.curToken) {
+		p.reservedNameError(p.curToken)
+	} else {
+		p.errorAt(p.curToken, "expected parameter name, got %s", p.curToken.Type)
+	}
+	return false
+}
+
+// reports whether tok was lexed as a keyword, a string like "if" is not one
+func isKeywordToken(tok token.Token) bool {
+	return tok.Type != token.IDENTIFIER && tok.Type == token.LookupIdent(tok.Literal)
+}
+
+func (p *Parser) reservedNameError(tok token.Token) {
+	p.errorAt(tok, "%q is a reserved word and cannot be used as a name, try another like %q",
+		tok.Literal, tok.Literal+"_")
+}
+
 func (p *Parser) parseIfExpression() ast.Expression {
 	stmt := &ast.IfExpression{Token: p.curToken}
 	if !p.expectPeek(token.LP) {
@@ -250,6 +356,120 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	return stmt
 
 }
+func (p *Parser) parseDoExpression() ast.Expression {
+	exp := &ast.DoExpression{Token: p.curToken}
+	if !p.expectPeek(token.LB) {
+		return nil
+	}
+	exp.Body = p.parseBlockStatement()
+	return exp
+}
+
+// the label after break or continue, nil if there is none. only a name on
+// the same line counts, one on the next line starts a new statement
+func (p *Parser) parseLoopLabel() *ast.Identifier {
+	if !p.peekTokenIs(token.IDENTIFIER) || p.peakToken.Line != p.curToken.Line {
+		return nil
+	}
+	p.nextToken()
+	return &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+}
+
+// name: while (...) { ... } or name: for (...) { ... }
+func (p *Parser) parseLabeledLoop() ast.Statement {
+	label := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	p.nextToken()
+	p.nextToken()
+	var stmt ast.Statement
+	switch p.curToken.Type {
+	case token.WHILE:
+		stmt = p.parseWhileStatement()
+	case token.FOR:
+		stmt = p.parseForStatement()
+	default:
+		p.errorAt(p.curToken, "label %s must be followed by a loop, got %s", label.Value, p.curToken.Type)
+		return nil
+	}
+	switch loop := stmt.(type) {
+	case *ast.WhileStatement:
+		loop.Label = label
+	case *ast.ForStatement:
+		loop.Label = label
+	case *ast.ForInStatement:
+		loop.Label = label
+	}
+	return stmt
+}
+
+func (p *Parser) parseWhileStatement() ast.Statement {
+	stmt := &ast.WhileStatement{Token: p.curToken}
+	if !p.expectPeek(token.LP) {
+		return nil
+	}
+	p.nextToken()
+	stmt.Condition = p.parseExpression(LOWEST)
+	if !p.expectPeek(token.RP) {
+		return nil
+	}
+	if !p.expectPeek(token.LB) {
+		return nil
+	}
+	stmt.Body = p.parseBlockStatement()
+	return stmt
+}
+
+func (p *Parser) parseForStatement() ast.Statement {
+	forToken := p.curToken
+	if !p.expectPeek(token.LP) {
+		return nil
+	}
+	p.nextToken()
+	if p.curTokenIs(token.IDENTIFIER) && p.peekTokenIs(token.IN) {
+		return p.parseForInStatement(forToken)
+	}
+	stmt := &ast.ForStatement{Token: forToken}
+	// a let or assignment swallows its own semicolon, an empty clause is the semicolon
+	stmt.Init = p.parseStatement()
+	if !p.curTokenIs(token.SEMICOLON) && !p.expectPeek(token.SEMICOLON) {
+		return nil
+	}
+	p.nextToken()
+	if !p.curTokenIs(token.SEMICOLON) {
+		stmt.Condition = p.parseExpression(LOWEST)
+		if !p.expectPeek(token.SEMICOLON) {
+			return nil
+		}
+	}
+	if !p.peekTokenIs(token.RP) {
+		p.nextToken()
+		stmt.Post = p.parseStatement()
+	}
+	if !p.expectPeek(token.RP) {
+		return nil
+	}
+	if !p.expectPeek(token.LB) {
+		return nil
+	}
+	stmt.Body = p.parseBlockStatement()
+	return stmt
+}
+
+func (p *Parser) parseForInStatement(forToken token.Token) ast.Statement {
+	stmt := &ast.ForInStatement{Token: forToken}
+	stmt.Variable = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	p.nextToken()
+	p.nextToken()
+	stmt.Collection = p.parseExpression(LOWEST)
+	if !p.expectPeek(token.RP) {
+		return nil
+	}
+	if !p.expectPeek(token.LB) {
+		return nil
+	}
+	stmt.Body = p.parseBlockStatement()
+	return stmt
+}
+
 func (p *Parser) parseBlockStatement() *ast.BlockStatements {
 	block := &ast.BlockStatements{Token: p.curToken}
 	block.Statements = []ast.Statement{}
@@ -261,6 +481,9 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatements {
 		}
 		p.nextToken()
 	}
+	if p.curTokenIs(token.EOF) {
+		p.errorAt(p.curToken, "unexpected end of input, expected %s", token.RB)
+	}
 	return block
 }
 
@@ -278,6 +501,10 @@ func (p *Parser) parseBoolean() ast.Expression {
 	return stmt
 }
 
+func (p *Parser) parseNull() ast.Expression {
+	return &ast.Null{Token: p.curToken}
+}
+
 func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	expression := &ast.InfixExpression{
 		Token:    p.curToken,
@@ -305,8 +532,7 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	lit := &ast.IntegerLiteral{Token: p.curToken}
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
 	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.errorAt(p.curToken, "could not parse %q as integer", p.curToken.Literal)
 		return nil
 	}
 	lit.Value = value
@@ -314,6 +540,17 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 
 }
 
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	lit := &ast.FloatLiteral{Token: p.curToken}
+	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
+	if err != nil {
+		p.errorAt(p.curToken, "could not parse %q as float", p.curToken.Literal)
+		return nil
+	}
+	lit.Value = value
+	return lit
+}
+
 func (p *Parser) parseIdentifier() ast.Expression {
 	stmt := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 	return stmt
@@ -324,7 +561,7 @@ func (p *Parser) parseReturnStatement() ast.Statement {
 	p.nextToken()
 	r.ReturnValue = p.parseExpression(LOWEST)
 
-	if p.peekTokenIs(token.SEMICOLON) {
+	if p.peekEndsStatement() {
 		p.nextToken()
 	}
 
@@ -333,6 +570,10 @@ func (p *Parser) parseReturnStatement() ast.Statement {
 
 func (p *Parser) parseLetStatement() ast.Statement {
 	stmt := &ast.LetStatement{Token: p.curToken}
+	if isKeywordToken(p.peakToken) {
+		p.reservedNameError(p.peakToken)
+		return nil
+	}
 	if !p.expectPeek(token.IDENTIFIER) {
 		return nil
 	}
@@ -343,7 +584,32 @@ func (p *Parser) parseLetStatement() ast.Statement {
 	p.nextToken()
 	stmt.Value = p.parseExpression(LOWEST)
 
-	if p.peekTokenIs(token.SEMICOLON) {
+	if p.peekEndsStatement() {
+		p.nextToken()
+	}
+	return stmt
+}
+
+func (p *Parser) parseSliceAssignStatement(target *ast.SliceExpression) ast.Statement {
+	p.nextToken()
+	stmt := &ast.SliceAssignStatement{Token: p.curToken, Target: target}
+	p.nextToken()
+	stmt.Value = p.parseExpression(LOWEST)
+
+	if p.peekEndsStatement() {
+		p.nextToken()
+	}
+	return stmt
+}
+
+func (p *Parser) parseAssignStatement() ast.Statement {
+	name := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	p.nextToken()
+	stmt := &ast.AssignStatement{Token: p.curToken, Name: name, Operator: p.curToken.Literal}
+	p.nextToken()
+	stmt.Value = p.parseExpression(LOWEST)
+
+	if p.peekEndsStatement() {
 		p.nextToken()
 	}
 	return stmt
@@ -361,10 +627,33 @@ func (p *Parser) parseArrayExpression() ast.Expression {
 }
 
 func (p *Parser) parseIndexExpression(leftExp ast.Expression) ast.Expression {
+	bracket := p.curToken
 	p.nextToken()
+	if p.curTokenIs(token.COLON) {
+		return p.parseSliceExpression(bracket, leftExp, nil)
+	}
 	exp := &ast.IndexExpression{Token: p.curToken, LeftExpression: leftExp}
 	index := p.parseExpression(LOWEST)
 	exp.Index = index
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		return p.parseSliceExpression(bracket, leftExp, index)
+	}
+	if !p.expectPeek(token.RSB) {
+		return nil
+	}
+	return exp
+}
+
+// parses the rest of left[start:end] with the colon as the current token
+func (p *Parser) parseSliceExpression(bracket token.Token, leftExp ast.Expression, start ast.Expression) ast.Expression {
+	exp := &ast.SliceExpression{Token: bracket, LeftExpression: leftExp, Start: start}
+	if p.peekTokenIs(token.RSB) {
+		p.nextToken()
+		return exp
+	}
+	p.nextToken()
+	exp.End = p.parseExpression(LOWEST)
 	if !p.expectPeek(token.RSB) {
 		return nil
 	}
@@ -386,14 +675,28 @@ func (p *Parser) expectPeek(t token.TokenType) bool {
 	return false
 }
 
+// reports whether the next token ends a statement, a semicolon or a
+// newline from a lexer that emits them
+func (p *Parser) peekEndsStatement() bool {
+	return p.peekTokenIs(token.SEMICOLON) || p.peekTokenIs(token.NEWLINE)
+}
+
 func (p *Parser) Errors() []string {
 	return p.errors
 }
 
+// the same errors as Errors, with the position kept apart from the message
+func (p *Parser) ErrorDetails() []ParseError {
+	return p.details
+}
+
 func (p *Parser) PeekError(t token.TokenType) {
-	msg := fmt.Sprintf("expected next token to be %s, got %s instead",
+	if p.peekTokenIs(token.EOF) {
+		p.errorAt(p.peakToken, "unexpected end of input, expected %s", t)
+		return
+	}
+	p.errorAt(p.peakToken, "expected next token to be %s, got %s instead",
 		t, p.peakToken.Type)
-	p.errors = append(p.errors, msg)
 }
 
 func (p *Parser) nextToken() {
@@ -410,8 +713,22 @@ func (p *Parser) registerPrefix(tokenType token.TokenType, fn prefixParseFns) {
 }
 
 func (p *Parser) noPrefixParseFnError(t token.TokenType) {
-	msg := fmt.Sprintf("no prefix parse function for %s found", t)
-	p.errors = append(p.errors, msg)
+	switch t {
+	case token.EOF:
+		p.errorAt(p.curToken, "unexpected end of input while parsing expression")
+		return
+	case token.ILLEGAL:
+		p.errorAt(p.curToken, "illegal token: %s", p.curToken.Literal)
+		return
+	}
+	p.errorAt(p.curToken, "no prefix parse function for %s found", t)
+}
+
+// records an error prefixed with the line and column of tok
+func (p *Parser) errorAt(tok token.Token, format string, a ...interface{}) {
+	msg := fmt.Sprintf(format, a...)
+	p.errors = append(p.errors, fmt.Sprintf("line %d:%d: ", tok.Line, tok.Column)+msg)
+	p.details = append(p.details, ParseError{Line: tok.Line, Column: tok.Column, Message: msg})
 }
 
 func (p *Parser) peekPrecedence() int {
@@ -131,6 +131,31 @@ func TestIntegerLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestFloatLiteralExpression(t *testing.T) {
+	input := "3.25;"
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+	if len(program.Statements) != 1 {
+		t.Fatalf("program has not enough statements. got=%d", len(program.Statements))
+	}
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+	}
+	literal, ok := stmt.Expression.(*ast.FloatLiteral)
+	if !ok {
+		t.Fatalf("exp not *ast.FloatLiteral. got=%T", stmt.Expression)
+	}
+	if literal.Value != 3.25 {
+		t.Errorf("literal.Value not %g. got=%g", 3.25, literal.Value)
+	}
+	if literal.TokenLiteral() != "3.25" {
+		t.Errorf("literal.TokenLiteral not %s. got=%s", "3.25", literal.TokenLiteral())
+	}
+}
+
 func TestParsingPrefixExpressions(t *testing.T) {
 	prefixTests := []struct {
 		input        string
@@ -195,10 +220,14 @@ func TestParsingInfixExpression(t *testing.T) {
 		{"5 - 5;", 5, "-", 5},
 		{"5 * 5;", 5, "*", 5},
 		{"5 / 5;", 5, "/", 5},
+		{"5 % 5;", 5, "%", 5},
+		{"7 ~/ 2;", 7, "~/", 2},
 		{"5 > 5;", 5, ">", 5},
 		{"5 < 5;", 5, "<", 5},
 		{"5 == 5;", 5, "==", 5},
 		{"5 != 5;", 5, "!=", 5},
+		{"5 <= 5;", 5, "<=", 5},
+		{"5 >= 5;", 5, ">=", 5},
 	}
 
 	for _, tt := range infixTests {
@@ -238,6 +267,14 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"-a * b",
 			"((-a) * b)",
 		},
+		{
+			"a || b && c",
+			"(a || (b && c))",
+		},
+		{
+			"a == b && c < d || !e",
+			"(((a == b) && (c < d)) || (!e))",
+		},
 		{
 			"!-a",
 			"(!(-a))",
@@ -258,6 +295,14 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"a * b / c",
 			"((a * b) / c)",
 		},
+		{
+			"a + b % c * d",
+			"(a + ((b % c) * d))",
+		},
+		{
+			"a + b ~/ c * d",
+			"(a + ((b ~/ c) * d))",
+		},
 		{
 			"a + b / c",
 			"(a + (b / c))",
@@ -685,3 +730,463 @@ func TestParsingHashLiteralsStringKeys(t *testing.T) {
 		testIntegerLiteral(t, value, expectedValue)
 	}
 }
+
+func TestDoExpressionParsing(t *testing.T) {
+	input := `let x = do { let a = 1; a + 2 };`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statement. got=%d",
+			len(program.Statements))
+	}
+	stmt, ok := program.Statements[0].(*ast.LetStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.LetStatement. got=%T",
+			program.Statements[0])
+	}
+	do, ok := stmt.Value.(*ast.DoExpression)
+	if !ok {
+		t.Fatalf("stmt.Value is not ast.DoExpression. got=%T", stmt.Value)
+	}
+	if len(do.Body.Statements) != 2 {
+		t.Fatalf("do body does not contain 2 statements. got=%d",
+			len(do.Body.Statements))
+	}
+	body, ok := do.Body.Statements[1].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("do.Body.Statements[1] is not ast.ExpressionStatement. got=%T",
+			do.Body.Statements[1])
+	}
+	testInfixExpression(t, body.Expression, "a", "+", 2)
+}
+
+func TestParserErrorPositions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x = 5;\nlet = 10;", "line 2:5: expected next token to be IDENTIFIER, got = instead"},
+		{"let x = 5;\n\n  add(1, 2;", "line 3:11: expected next token to be ), got ; instead"},
+		{"1 +\n  ;", "line 2:3: no prefix parse function for ; found"},
+		{"let v = 1.2.3;", "line 1:9: illegal token: invalid float literal 1.2.3"},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %q", tt.input)
+			continue
+		}
+		if errors[0] != tt.expected {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expected, errors[0])
+		}
+		details := p.ErrorDetails()
+		if len(details) != len(errors) {
+			t.Errorf("expected %d error details, got=%d", len(errors), len(details))
+			continue
+		}
+		d := details[0]
+		if got := fmt.Sprintf("line %d:%d: %s", d.Line, d.Column, d.Message); got != tt.expected {
+			t.Errorf("wrong error details. expected=%q, got=%q", tt.expected, got)
+		}
+	}
+}
+
+func TestTruncatedInputErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x = (1 +", "line 1:13: unexpected end of input while parsing expression"},
+		{"let x", "line 1:6: unexpected end of input, expected ="},
+		{"let x =", "line 1:8: unexpected end of input while parsing expression"},
+		{"[1, 2", "line 1:6: unexpected end of input, expected ]"},
+		{"if (x", "line 1:6: unexpected end of input, expected )"},
+		{"fn(x) { x + 1", "line 1:14: unexpected end of input, expected }"},
+		{`let s = "abc`, "line 1:9: illegal token: unterminated string"},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %q", tt.input)
+			continue
+		}
+		if errors[0] != tt.expected {
+			t.Errorf("wrong error message for %q. expected=%q, got=%q", tt.input, tt.expected, errors[0])
+		}
+	}
+}
+
+func TestParsingSliceExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"arr[1:2]", "(arr[1:2])"},
+		{"arr[:2]", "(arr[:2])"},
+		{"arr[1:]", "(arr[1:])"},
+		{"arr[:]", "(arr[:])"},
+		{"arr[-2:]", "(arr[(-2):])"},
+		{"arr[:-1]", "(arr[:(-1)])"},
+		{"arr[1 + 1:len(arr) - 1]", "(arr[(1 + 1):(len(arr) - 1)])"},
+		{"arr[1]", "(arr[1])"},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	p := New(lexer.New("arr[1:2]"))
+	program := p.ParseProgram()
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	slice, ok := stmt.Expression.(*ast.SliceExpression)
+	if !ok {
+		t.Fatalf("exp not *ast.SliceExpression. got=%T", stmt.Expression)
+	}
+	testIdentifier(t, slice.LeftExpression, "arr")
+	testIntegerLiteral(t, slice.Start, 1)
+	testIntegerLiteral(t, slice.End, 2)
+}
+
+func TestEmptyStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{"1; ; 2;", 2},
+		{";;;", 0},
+		{"let x = 1;; x", 2},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+		if len(program.Statements) != tt.expected {
+			t.Errorf("wrong number of statements for %q. got=%d, want=%d",
+				tt.input, len(program.Statements), tt.expected)
+		}
+	}
+
+	p := New(lexer.New("fn() { ; 1; ; }"))
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+	function := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+	if len(function.Body.Statements) != 1 {
+		t.Errorf("wrong number of body statements. got=%d", len(function.Body.Statements))
+	}
+}
+
+func TestReservedNames(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let if = 1;", `line 1:5: "if" is a reserved word and cannot be used as a name, try another like "if_"`},
+		{"let fn = 1;", `line 1:5: "fn" is a reserved word and cannot be used as a name, try another like "fn_"`},
+		{"fn(x, return) { x }", `line 1:7: "return" is a reserved word and cannot be used as a name, try another like "return_"`},
+		{"fn(1) { 1 }", "line 1:4: expected parameter name, got INT"},
+		// a string spelling a keyword is just the wrong kind of token
+		{`let "if" = 1;`, "line 1:5: expected next token to be IDENTIFIER, got STRING instead"},
+		{`fn("if") { 1 }`, "line 1:4: expected parameter name, got STRING"},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %q", tt.input)
+			continue
+		}
+		if errors[0] != tt.expected {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expected, errors[0])
+		}
+	}
+}
+
+func TestParsingHashSpreads(t *testing.T) {
+	input := `{...base, "b": 2, ...other}`
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	hash, ok := stmt.Expression.(*ast.HashExpression)
+	if !ok {
+		t.Fatalf("exp is not ast.HashExpression. got=%T", stmt.Expression)
+	}
+	if len(hash.Spreads) != 2 {
+		t.Fatalf("hash.Spreads has wrong length. got=%d", len(hash.Spreads))
+	}
+	testIdentifier(t, hash.Spreads[0], "base")
+	testIdentifier(t, hash.Spreads[1], "other")
+	if len(hash.Pairs) != 1 {
+		t.Errorf("hash.Pairs has wrong length. got=%d", len(hash.Pairs))
+	}
+}
+
+func TestCompoundAssignStatements(t *testing.T) {
+	tests := []struct {
+		input            string
+		expectedName     string
+		expectedOperator string
+		expectedValue    interface{}
+	}{
+		{"x = 5;", "x", "=", 5},
+		{"x += 4;", "x", "+=", 4},
+		{"x -= y", "x", "-=", "y"},
+		{"total *= 2;", "total", "*=", 2},
+		{"x /= 3", "x", "/=", 3},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		}
+		stmt, ok := program.Statements[0].(*ast.AssignStatement)
+		if !ok {
+			t.Fatalf("stmt is not ast.AssignStatement. got=%T", program.Statements[0])
+		}
+		if stmt.Name.Value != tt.expectedName {
+			t.Errorf("stmt.Name.Value not %s. got=%s", tt.expectedName, stmt.Name.Value)
+		}
+		if stmt.Operator != tt.expectedOperator {
+			t.Errorf("stmt.Operator not %s. got=%s", tt.expectedOperator, stmt.Operator)
+		}
+		testLiteralExpression(t, stmt.Value, tt.expectedValue)
+	}
+}
+
+func TestWhileStatement(t *testing.T) {
+	input := `while (x < 10) { x += 1; }`
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+	}
+	stmt, ok := program.Statements[0].(*ast.WhileStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.WhileStatement. got=%T", program.Statements[0])
+	}
+	if !testInfixExpression(t, stmt.Condition, "x", "<", 10) {
+		return
+	}
+	if len(stmt.Body.Statements) != 1 {
+		t.Fatalf("body is not 1 statements. got=%d", len(stmt.Body.Statements))
+	}
+	if _, ok := stmt.Body.Statements[0].(*ast.AssignStatement); !ok {
+		t.Errorf("body.Statements[0] is not ast.AssignStatement. got=%T", stmt.Body.Statements[0])
+	}
+}
+
+func TestForStatement(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"for (let i = 0; i < 3; i += 1) { puts(i); }", "for (let i = 0; (i < 3); i += 1) puts(i)"},
+		{"for (; i < 3;) { i }", "for (; (i < 3); ) i"},
+		{"for (;;) { 1 }", "for (; ; ) 1"},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		}
+		stmt, ok := program.Statements[0].(*ast.ForStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.ForStatement. got=%T", program.Statements[0])
+		}
+		if stmt.String() != tt.expected {
+			t.Errorf("wrong String(). expected=%q, got=%q", tt.expected, stmt.String())
+		}
+	}
+}
+
+func TestForInStatement(t *testing.T) {
+	input := `for (item in [1, 2]) { puts(item); }`
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+	}
+	stmt, ok := program.Statements[0].(*ast.ForInStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ForInStatement. got=%T", program.Statements[0])
+	}
+	testIdentifier(t, stmt.Variable, "item")
+	if _, ok := stmt.Collection.(*ast.Array); !ok {
+		t.Errorf("stmt.Collection is not ast.Array. got=%T", stmt.Collection)
+	}
+	if len(stmt.Body.Statements) != 1 {
+		t.Errorf("body is not 1 statements. got=%d", len(stmt.Body.Statements))
+	}
+}
+
+func TestBreakContinueStatements(t *testing.T) {
+	input := `while (true) { break; continue }`
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+	stmt, ok := program.Statements[0].(*ast.WhileStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.WhileStatement. got=%T", program.Statements[0])
+	}
+	if len(stmt.Body.Statements) != 2 {
+		t.Fatalf("body is not 2 statements. got=%d", len(stmt.Body.Statements))
+	}
+	if _, ok := stmt.Body.Statements[0].(*ast.BreakStatement); !ok {
+		t.Errorf("body.Statements[0] is not ast.BreakStatement. got=%T", stmt.Body.Statements[0])
+	}
+	if _, ok := stmt.Body.Statements[1].(*ast.ContinueStatement); !ok {
+		t.Errorf("body.Statements[1] is not ast.ContinueStatement. got=%T", stmt.Body.Statements[1])
+	}
+}
+
+func TestLabeledLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"outer: while (true) { break outer; }", "outer: whiletrue break outer;"},
+		{"l: for (x in xs) { continue l }", "l: for (x in xs) continue l;"},
+		{"l: for (;;) { break; }", "l: for (; ; ) break;"},
+		// a name on the next line is a statement of its own, not a label
+		{"while (true) { break\nfoo }", "whiletrue break;foo"},
+		{"while (true) { break\nfoo: while (x) { continue foo } }", "whiletrue break;foo: whilex continue foo;"},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		}
+		if program.String() != tt.expected {
+			t.Errorf("wrong String(). expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	p := New(lexer.New("outer: x + 1"))
+	p.ParseProgram()
+	errors := p.Errors()
+	if len(errors) == 0 || errors[0] != "line 1:8: label outer must be followed by a loop, got IDENTIFIER" {
+		t.Errorf("expected a label error, got=%v", errors)
+	}
+}
+
+func TestSliceAssignStatement(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"arr[1:3] = [x, y];", "(arr[1:3]) = [x, y];"},
+		{"arr[:] = []", "(arr[:]) = [];"},
+		{"arr[2:] = b", "(arr[2:]) = b;"},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		}
+		stmt, ok := program.Statements[0].(*ast.SliceAssignStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.SliceAssignStatement. got=%T", program.Statements[0])
+		}
+		if stmt.String() != tt.expected {
+			t.Errorf("wrong String(). expected=%q, got=%q", tt.expected, stmt.String())
+		}
+	}
+}
+
+func TestMalformedPrefixDoesNotReachInfix(t *testing.T) {
+	tests := []string{
+		"* 5",
+		"fn + 1",
+		"do * 2",
+		"if (x) { 1 } else + 2",
+		"let f = fn - 1;",
+		"add(fn == 1)",
+	}
+	for _, input := range tests {
+		p := New(lexer.New(input))
+		program := p.ParseProgram()
+		if len(p.Errors()) == 0 {
+			t.Errorf("expected parser errors for %q", input)
+		}
+		// a nil left operand would panic here when the infix node is printed
+		_ = program.String()
+	}
+}
+
+func TestNewlineTerminatedStatements(t *testing.T) {
+	input := `let x = 5
+let y = x
+-y
+let add = fn(a, b) {
+  let sum = a + b
+  sum
+}
+add(x,
+  y)`
+	p := New(lexer.NewWithNewlines(input))
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+	expected := []string{
+		"let x = 5;",
+		"let y = x;",
+		"(-y)",
+		"let add = fn(a, b)let sum = (a + b);sum;",
+		"add(x, y)",
+	}
+	if len(program.Statements) != len(expected) {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d", len(expected), len(program.Statements))
+	}
+	for i, want := range expected {
+		if got := program.Statements[i].String(); got != want {
+			t.Errorf("statement %d wrong. expected=%q, got=%q", i, want, got)
+		}
+	}
+
+	// else and an opening brace may start the line after an if or fn header
+	layouts := []struct {
+		input    string
+		expected string
+	}{
+		{"if (true) { 1 }\nelse { 2 }", "iftrue 1else 2"},
+		{"if (x)\n{\n  1\n}\nelse\n{\n  2\n}", "ifx 1else 2"},
+		{"let f = fn(a)\n{\n  a\n}\nf(1)", "let f = fn(a)a;f(1)"},
+		{"if (x) { 1 }\nelsewhere", "ifx 1elsewhere"},
+	}
+	for _, tt := range layouts {
+		p := New(lexer.NewWithNewlines(tt.input))
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+		if got := program.String(); got != tt.expected {
+			t.Errorf("%q parsed wrong. expected=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+
+	// by default the line break is whitespace, so x and -y form one expression
+	p = New(lexer.New("let y = x\n-y"))
+	program = p.ParseProgram()
+	checkParseErrors(t, p)
+	if got := program.String(); got != "let y = (x - y);" {
+		t.Errorf("default parse wrong. got=%q", got)
+	}
+}
@@ -8,39 +8,106 @@ import (
 	"interpreter/object"
 	"interpreter/parser"
 	"io"
+	"os"
+	"strings"
 )
 
 const PROMPT = ">> "
 
+const WELCOME = "This is Monkey Language\nType any commands\n"
+
+// settings for embedding the REPL in other tools
+type Config struct {
+	Prompt      string
+	In          io.Reader
+	Out         io.Writer
+	Err         io.Writer // parse and runtime errors, Out when nil
+	ShowWelcome bool
+}
+
 func Start(in io.Reader, out io.Writer) {
-	scanner := bufio.NewScanner(in)
+	StartWithConfig(Config{Prompt: PROMPT, In: in, Out: out})
+}
+
+func StartWithConfig(cfg Config) {
+	in, out := cfg.In, cfg.Out
+	errOut := cfg.Err
+	if errOut == nil {
+		errOut = out
+	}
+	// the previous writers are read now, so scripts print to ours only while the REPL runs
+	defer evaluator.SetOutput(evaluator.GetIO().Out)
+	evaluator.SetOutput(out)
+	defer evaluator.SetErrorOutput(evaluator.GetIO().Err)
+	evaluator.SetErrorOutput(errOut)
+	// `input` reads from the same buffered reader as the prompt, so neither
+	// swallows lines meant for the other
+	reader := bufio.NewReader(in)
+	defer evaluator.SetInput(evaluator.GetIO().In)
+	evaluator.SetInput(reader)
+	if cfg.ShowWelcome {
+		io.WriteString(out, WELCOME)
+	}
 	env := object.NewEnviroment()
 	for {
-		fmt.Printf(PROMPT)
-		scanned := scanner.Scan()
-		if !scanned {
+		io.WriteString(out, cfg.Prompt)
+		line, err := reader.ReadString('\n')
+		if err != nil && line == "" {
 			return
 		}
-		line := scanner.Text()
+		line = strings.TrimSuffix(line, "\n")
+		line = strings.TrimSuffix(line, "\r")
 		l := lexer.New(line)
 		p := parser.New(l)
 
 		program := p.ParseProgram()
 		if len(p.Errors()) != 0 {
-			printParseErrors(out, p.Errors())
+			printParseErrors(errOut, p.Errors())
 			continue
 		}
 
 		evaluated := evaluator.Eval(program, env)
 
 		if evaluated != nil {
-			io.WriteString(out, evaluated.Inspect())
-			io.WriteString(out, "\n")
+			w := out
+			if evaluated.Type() == object.ERROR_OBJ {
+				w = errOut
+			}
+			io.WriteString(w, evaluated.Inspect())
+			io.WriteString(w, "\n")
 		}
 	}
 
 }
 
+// runs the script at path with args exposed through the `args` builtin,
+// errors are written to the evaluator's error writer
+func RunFile(path string, args []string, out io.Writer) (object.Object, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	evaluator.ScriptArgs = args
+	defer evaluator.SetOutput(evaluator.GetIO().Out)
+	evaluator.SetOutput(out)
+
+	l := lexer.New(string(src))
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		printParseErrors(evaluator.GetIO().Err, p.Errors())
+		return nil, fmt.Errorf("%s: %d parse error(s)", path, len(p.Errors()))
+	}
+
+	evaluated := evaluator.Eval(program, object.NewEnviroment())
+	if evaluated != nil && evaluated.Type() == object.ERROR_OBJ {
+		errOut := evaluator.GetIO().Err
+		io.WriteString(errOut, evaluated.Inspect())
+		io.WriteString(errOut, "\n")
+	}
+	return evaluated, nil
+}
+
 func printParseErrors(out io.Writer, errors []string) {
 	for _, msg := range errors {
 		io.WriteString(out, "\t"+msg+"\n")
@@ -0,0 +1,134 @@
+package repl
+
+import (
+	"bytes"
+	"interpreter/evaluator"
+	"interpreter/object"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunFileArgs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "script.mk")
+	if err := os.WriteFile(path, []byte("args();"), 0644); err != nil {
+		t.Fatalf("could not write script: %s", err)
+	}
+
+	var out bytes.Buffer
+	evaluated, err := RunFile(path, []string{"one", "two"}, &out)
+	if err != nil {
+		t.Fatalf("RunFile returned error: %s", err)
+	}
+	arr, ok := evaluated.(*object.Array)
+	if !ok {
+		t.Fatalf("object is not Array. got=%T (%+v)", evaluated, evaluated)
+	}
+	expected := []string{"one", "two"}
+	if len(arr.Elements) != len(expected) {
+		t.Fatalf("wrong number of args. got=%d, want=%d", len(arr.Elements), len(expected))
+	}
+	for i, want := range expected {
+		str, ok := arr.Elements[i].(*object.String)
+		if !ok || str.Value != want {
+			t.Errorf("args()[%d] wrong. got=%+v, want=%q", i, arr.Elements[i], want)
+		}
+	}
+}
+
+func TestRunFileErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "script.mk")
+	if err := os.WriteFile(path, []byte("puts(1); missing"), 0644); err != nil {
+		t.Fatalf("could not write script: %s", err)
+	}
+
+	var out, errOut bytes.Buffer
+	defer evaluator.SetErrorOutput(evaluator.GetIO().Err)
+	evaluator.SetErrorOutput(&errOut)
+	if _, err := RunFile(path, nil, &out); err != nil {
+		t.Fatalf("RunFile returned error: %s", err)
+	}
+	if out.String() != "1\n" {
+		t.Errorf("wrong output. got=%q", out.String())
+	}
+	if errOut.String() != "ERROR: line 1: identifier not found: missing\n" {
+		t.Errorf("wrong errors. got=%q", errOut.String())
+	}
+}
+
+func TestStartWithConfigPrompt(t *testing.T) {
+	var out bytes.Buffer
+	StartWithConfig(Config{
+		Prompt: "monkey> ",
+		In:     strings.NewReader("1 + 2\n"),
+		Out:    &out,
+	})
+
+	expected := "monkey> 3\nmonkey> "
+	if out.String() != expected {
+		t.Errorf("wrong REPL output. expected=%q, got=%q", expected, out.String())
+	}
+}
+
+func TestStartWithConfigOutput(t *testing.T) {
+	var out bytes.Buffer
+	StartWithConfig(Config{
+		Prompt: ">> ",
+		In:     strings.NewReader("puts(\"hi\")\n"),
+		Out:    &out,
+	})
+
+	expected := ">> hi\nnull\n>> "
+	if out.String() != expected {
+		t.Errorf("wrong REPL output. expected=%q, got=%q", expected, out.String())
+	}
+}
+
+func TestStartWithConfigErrors(t *testing.T) {
+	var out, errOut bytes.Buffer
+	StartWithConfig(Config{
+		Prompt: ">> ",
+		In:     strings.NewReader("1 +\nfoo\nlet len = 1;\n2\n"),
+		Out:    &out,
+		Err:    &errOut,
+	})
+
+	if out.String() != ">> >> >> >> 2\n>> " {
+		t.Errorf("wrong REPL output. got=%q", out.String())
+	}
+	expected := "\tline 1:4: unexpected end of input while parsing expression\n" +
+		"ERROR: line 1: identifier not found: foo\n" +
+		"warning: line 1: let len shadows the builtin `len`\n"
+	if errOut.String() != expected {
+		t.Errorf("wrong REPL errors. expected=%q, got=%q", expected, errOut.String())
+	}
+}
+
+func TestStartWithConfigInput(t *testing.T) {
+	var out bytes.Buffer
+	StartWithConfig(Config{
+		Prompt: ">> ",
+		In:     strings.NewReader("let name = input();\nAda\nname\n"),
+		Out:    &out,
+	})
+
+	expected := ">> >> Ada\n>> "
+	if out.String() != expected {
+		t.Errorf("wrong REPL output. expected=%q, got=%q", expected, out.String())
+	}
+}
+
+func TestStartWithConfigWelcome(t *testing.T) {
+	var out bytes.Buffer
+	StartWithConfig(Config{
+		Prompt:      ">> ",
+		In:          strings.NewReader(""),
+		Out:         &out,
+		ShowWelcome: true,
+	})
+
+	if !strings.HasPrefix(out.String(), WELCOME) {
+		t.Errorf("welcome message missing. got=%q", out.String())
+	}
+}
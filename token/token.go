@@ -5,16 +5,25 @@ type TokenType string
 type Token struct {
 	Type    TokenType
 	Literal string
+	Line    int
+	Column  int
 }
 
 var keywords = map[string]TokenType{
-	"let":    LET,
-	"fn":     FUNC,
-	"true":   TRUE,
-	"false":  FALSE,
-	"if":     IF,
-	"else":   ELSE,
-	"return": RETURN,
+	"let":      LET,
+	"fn":       FUNC,
+	"true":     TRUE,
+	"false":    FALSE,
+	"if":       IF,
+	"else":     ELSE,
+	"return":   RETURN,
+	"do":       DO,
+	"while":    WHILE,
+	"for":      FOR,
+	"in":       IN,
+	"break":    BREAK,
+	"continue": CONTINUE,
+	"null":     NULL,
 }
 
 // looks up if the string is LET FUNC or an IDENTIFIER
@@ -31,35 +40,55 @@ const (
 
 	IDENTIFIER = "IDENTIFIER"
 	INT        = "INT"
+	FLOAT      = "FLOAT"
 
-	ASSIGN = "="
-	PLUS   = "+"
-	MINUS  = "-"
-	EQ     = "=="
-	NEQ    = "!="
-	STAR   = "*"
-	GR     = ">"
-	LE     = "<"
-	SLASH  = "/"
-	EXCLA  = "!"
+	ASSIGN    = "="
+	PLUS      = "+"
+	MINUS     = "-"
+	EQ        = "=="
+	NEQ       = "!="
+	STAR      = "*"
+	GR        = ">"
+	LE        = "<"
+	LESSEQ    = "<="
+	GREATEREQ = ">="
+	SLASH     = "/"
+	PERCENT   = "%"
+	FLOORDIV  = "~/"
+	EXCLA     = "!"
+	PLUSEQ    = "+="
+	MINUSEQ   = "-="
+	STAREQ    = "*="
+	SLASHEQ   = "/="
+	AND       = "&&"
+	OR        = "||"
 
 	COMMA     = ","
 	SEMICOLON = ";"
+	NEWLINE   = "NEWLINE"
 	LP        = "("
 	RP        = ")"
 	LB        = "{"
 	RB        = "}"
 
-	LET    = "LET"
-	FUNC   = "FUNCTION"
-	TRUE   = "TRUE"
-	FALSE  = "FALSE"
-	RETURN = "RETURN"
-	IF     = "IF"
-	ELSE   = "ELSE"
-	STRING = "STRING"
+	LET      = "LET"
+	FUNC     = "FUNCTION"
+	TRUE     = "TRUE"
+	FALSE    = "FALSE"
+	RETURN   = "RETURN"
+	IF       = "IF"
+	ELSE     = "ELSE"
+	DO       = "DO"
+	WHILE    = "WHILE"
+	FOR      = "FOR"
+	IN       = "IN"
+	BREAK    = "BREAK"
+	CONTINUE = "CONTINUE"
+	NULL     = "NULL"
+	STRING   = "STRING"
 
-	LSB   = "["
-	RSB   = "]"
-	COLON = ":"
+	LSB      = "["
+	RSB      = "]"
+	COLON    = ":"
+	ELLIPSIS = "..."
 )